@@ -12,6 +12,47 @@ metrics:
   bindAddress: :{{ .Values.metrics.port }}
 {{ end }}
 
+{{ if .Values.integrationConcurrency }}
+integrationConcurrency: {{ .Values.integrationConcurrency }}
+{{ end }}
+
+{{ if .Values.dependencyTimeout }}
+dependencyTimeout: {{ .Values.dependencyTimeout }}
+{{ end }}
+
+{{ if .Values.dryRun }}
+dryRun: true
+{{ end }}
+
+{{ if .Values.routeStatus.enabled }}
+routeStatus:
+  enabled: true
+{{ end }}
+
+{{ if .Values.cloudEvents.sinkURL }}
+cloudEvents:
+  sinkURL: {{ .Values.cloudEvents.sinkURL | quote }}
+  {{ if .Values.cloudEvents.source }}
+  source: {{ .Values.cloudEvents.source | quote }}
+  {{ end }}
+{{ end }}
+
+{{ if .Values.tracing.endpoint }}
+tracing:
+  endpoint: {{ .Values.tracing.endpoint | quote }}
+  protocol: {{ .Values.tracing.protocol }}
+  insecure: {{ .Values.tracing.insecure }}
+  samplingRatio: {{ .Values.tracing.samplingRatio }}
+{{ end }}
+
+{{ if .Values.admission.enabled }}
+admission:
+  port: {{ .Values.admission.port }}
+  certDir: /etc/switchboard/tls
+  mode: {{ .Values.admission.mode }}
+  failOpen: {{ .Values.admission.failOpen }}
+{{ end }}
+
 {{ if .Values.selector.ingressClass }}
 selector:
   ingressClass: {{ .Values.selector.ingressClass }}
@@ -19,7 +60,15 @@ selector:
 
 {{- $certManager := .Values.integrations.certManager -}}
 {{- $externalDNS := .Values.integrations.externalDNS -}}
-{{ if or $certManager.enabled $externalDNS.enabled }}
+{{- $rfc2136 := .Values.integrations.rfc2136 -}}
+{{- $coreDNS := .Values.integrations.coreDNS -}}
+{{- $redirect := .Values.integrations.redirect -}}
+{{- $tlsStore := .Values.integrations.tlsStore -}}
+{{- $probe := .Values.integrations.probe -}}
+{{- $reflector := .Values.integrations.reflector -}}
+{{- $webhook := .Values.integrations.webhook -}}
+{{- $templates := .Values.integrations.templates -}}
+{{ if or $certManager.enabled $externalDNS.enabled $rfc2136.enabled $coreDNS.enabled $redirect.enabled $tlsStore.enabled $probe.enabled $reflector.enabled $webhook.enabled $templates }}
 integrations:
   {{ if $certManager.enabled }}
   certManager:
@@ -35,23 +84,182 @@ integrations:
     {{ else }}
       {{ fail "certificate template is not provided and no issuer is created by this chart" }}
     {{ end }}
+    {{ with $certManager.dependsOn }}
+    dependsOn:
+      {{ toYaml . | nindent 6 }}
+    {{ end }}
   {{ end }}
   {{ if $externalDNS.enabled }}
   externalDNS:
+    {{ if or (and $externalDNS.targetService.name $externalDNS.targetService.namespace) $externalDNS.targetServices }}
     {{ if and $externalDNS.targetService.name $externalDNS.targetService.namespace }}
     targetService:
       name: {{ $externalDNS.targetService.name }}
       namespace: {{ $externalDNS.targetService.namespace }}
+    {{ end }}
+    {{ if $externalDNS.targetServices }}
+    targetServices:
+      {{ toYaml $externalDNS.targetServices | nindent 6 }}
+    {{ end }}
     {{ else if $externalDNS.targetIPs }}
     targetIPs:
       {{ toYaml $externalDNS.targetIPs | nindent 6 }}
+    {{ else if $externalDNS.targetNodes.enabled }}
+    targetNodes:
+      {{ if $externalDNS.targetNodes.selector }}
+      selector:
+        {{ toYaml $externalDNS.targetNodes.selector | nindent 8 }}
+      {{ end }}
+      {{ if $externalDNS.targetNodes.addressTypes }}
+      addressTypes:
+        {{ toYaml $externalDNS.targetNodes.addressTypes | nindent 8 }}
+      {{ end }}
     {{ else }}
-      {{ fail "exactly one of target service and target IPs must be set for external dns" }}
+      {{ fail "exactly one of target service, target IPs and target nodes must be set for external dns" }}
+    {{ end }}
+    {{ with $externalDNS.targetFilter }}
+    {{ if or .ipFamilyPolicy .allowCIDRs .denyCIDRs }}
+    targetFilter:
+      {{ if .ipFamilyPolicy }}
+      ipFamilyPolicy: {{ .ipFamilyPolicy }}
+      {{ end }}
+      {{ if .allowCIDRs }}
+      allowCIDRs:
+        {{ toYaml .allowCIDRs | nindent 8 }}
+      {{ end }}
+      {{ if .denyCIDRs }}
+      denyCIDRs:
+        {{ toYaml .denyCIDRs | nindent 8 }}
+      {{ end }}
+    {{ end }}
+    {{ end }}
+    {{ if $externalDNS.caaRecords }}
+    caaRecords:
+      {{ toYaml $externalDNS.caaRecords | nindent 6 }}
+    {{ end }}
+    {{ if $externalDNS.httpsRecords.entryPoints }}
+    httpsRecords:
+      entryPoints:
+        {{ toYaml $externalDNS.httpsRecords.entryPoints | nindent 8 }}
+      {{ if $externalDNS.httpsRecords.alpn }}
+      alpn:
+        {{ toYaml $externalDNS.httpsRecords.alpn | nindent 8 }}
+      {{ end }}
+    {{ end }}
+    {{ if $externalDNS.extraRecords.allowedTypes }}
+    extraRecords:
+      allowedTypes:
+        {{ toYaml $externalDNS.extraRecords.allowedTypes | nindent 8 }}
     {{ end }}
     {{ if $externalDNS.ttl }}
     ttl: {{ $externalDNS.ttl }}
     {{ end }}
   {{ end }}
+  {{ if $rfc2136.enabled }}
+  rfc2136:
+    address: {{ required "address must be set for rfc2136" $rfc2136.address | quote }}
+    zone: {{ required "zone must be set for rfc2136" $rfc2136.zone | quote }}
+    {{ if $rfc2136.tsig.keyName }}
+    tsigKeyName: {{ $rfc2136.tsig.keyName | quote }}
+    {{ if $rfc2136.tsig.algorithm }}
+    tsigAlgorithm: {{ $rfc2136.tsig.algorithm | quote }}
+    {{ end }}
+    {{ if $rfc2136.tsig.secretName }}
+    tsigSecretEnv: RFC2136_TSIG_SECRET
+    {{ end }}
+    {{ end }}
+    {{ if $rfc2136.ownerID }}
+    ownerID: {{ $rfc2136.ownerID | quote }}
+    {{ end }}
+    {{ if and $rfc2136.targetService.name $rfc2136.targetService.namespace }}
+    targetService:
+      name: {{ $rfc2136.targetService.name }}
+      namespace: {{ $rfc2136.targetService.namespace }}
+    {{ else if $rfc2136.targetIPs }}
+    targetIPs:
+      {{ toYaml $rfc2136.targetIPs | nindent 6 }}
+    {{ else }}
+      {{ fail "either target service or target IPs must be set for rfc2136" }}
+    {{ end }}
+    {{ if $rfc2136.ttl }}
+    ttl: {{ $rfc2136.ttl }}
+    {{ end }}
+  {{ end }}
+  {{ if $coreDNS.enabled }}
+  coreDNS:
+    {{ if and $coreDNS.targetService.name $coreDNS.targetService.namespace }}
+    targetService:
+      name: {{ $coreDNS.targetService.name }}
+      namespace: {{ $coreDNS.targetService.namespace }}
+    {{ else }}
+      {{ fail "target service must be set for coredns" }}
+    {{ end }}
+    configMap:
+      name: {{ $coreDNS.configMap.name }}
+      namespace: {{ $coreDNS.configMap.namespace }}
+    {{ if $coreDNS.ttl }}
+    ttl: {{ $coreDNS.ttl }}
+    {{ end }}
+  {{ end }}
+  {{ if $redirect.enabled }}
+  redirect:
+    entryPoint: {{ $redirect.entryPoint }}
+    middleware:
+      name: {{ required "middleware name must be set for redirect" $redirect.middleware.name }}
+      {{ if $redirect.middleware.namespace }}
+      namespace: {{ $redirect.middleware.namespace }}
+      {{ end }}
+  {{ end }}
+  {{ if $tlsStore.enabled }}
+  tlsStore:
+    namespace: {{ required "namespace must be set for tls store" $tlsStore.namespace | quote }}
+    mode: {{ $tlsStore.mode }}
+    {{ if $tlsStore.options }}
+    options:
+      {{ toYaml $tlsStore.options | nindent 6 }}
+    {{ end }}
+  {{ end }}
+  {{ if $probe.enabled }}
+  probe:
+    proberURL: {{ required "prober URL must be set for probe" $probe.proberURL | quote }}
+    {{ if $probe.module }}
+    module: {{ $probe.module }}
+    {{ end }}
+    {{ if $probe.labels }}
+    labels:
+      {{ toYaml $probe.labels | nindent 6 }}
+    {{ end }}
+  {{ end }}
+  {{ if $reflector.enabled }}
+  reflector:
+    allowedNamespaces:
+      {{ toYaml $reflector.allowedNamespaces | nindent 6 }}
+  {{ end }}
+  {{ if $webhook.enabled }}
+  webhook:
+    url: {{ required "url must be set for webhook" $webhook.url | quote }}
+    {{ if $webhook.hmac.secretName }}
+    secretEnv: WEBHOOK_HMAC_SECRET
+    {{ end }}
+    {{ if not (kindIs "invalid" $webhook.maxRetries) }}
+    maxRetries: {{ $webhook.maxRetries }}
+    {{ end }}
+    {{ if and $webhook.targetService.name $webhook.targetService.namespace }}
+    targetService:
+      name: {{ $webhook.targetService.name }}
+      namespace: {{ $webhook.targetService.namespace }}
+    {{ else if $webhook.targetIPs }}
+    targetIPs:
+      {{ toYaml $webhook.targetIPs | nindent 6 }}
+    {{ end }}
+    state:
+      name: {{ .Release.Name }}-webhook
+      namespace: {{ .Release.Namespace }}
+  {{ end }}
+  {{ if $templates }}
+  templates:
+    {{ toYaml $templates | nindent 4 }}
+  {{ end }}
 {{ end }}
 
 {{ end }}
@@ -5,3 +5,11 @@
 {{ .Chart.Version }}
 {{- end -}}
 {{ end }}
+
+{{ define "admission.secretName" }}
+{{- if .Values.admission.certificate.create -}}
+{{ .Release.Name }}-webhook-tls
+{{- else -}}
+{{ required "secret name must be set if no webhook certificate is created" .Values.admission.certificate.secretName }}
+{{- end -}}
+{{ end }}
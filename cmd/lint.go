@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/borchero/switchboard/internal/controllers"
+	"github.com/borchero/switchboard/internal/k8s"
+	"github.com/borchero/switchboard/internal/lint"
+	"github.com/borchero/switchboard/internal/switchboard"
+	traefik "github.com/traefik/traefik/v3/pkg/provider/kubernetes/crd/traefikio/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const lintUsage = `Usage: switchboard lint [flags] [path...]
+
+Checks the ingress routes in the given files and directories (or the current directory if no paths
+are given) for problems that would prevent Switchboard from processing them as intended. Exits with
+a non-zero code if any errors are found.
+
+Flags:
+`
+
+var errLintFailed = errors.New("linting found errors")
+
+// runLint runs the `lint` subcommand with the given arguments.
+func runLint(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), lintUsage)
+		flags.PrintDefaults()
+	}
+	cfgFile := flags.String("config", "", "The config file to use.")
+	format := flags.String("format", lint.FormatText, "The output format, one of text, json and sarif.")
+	namespace := flags.String(
+		"namespace", "default", "The namespace of ingress routes without namespace.",
+	)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *cfgFile == "" {
+		return fmt.Errorf("the `-config` flag is required")
+	}
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	// First, we load the configuration along with the ingress routes
+	config, err := loadConfig(*cfgFile)
+	if err != nil {
+		return err
+	}
+	names, err := controllers.IntegrationNames(config)
+	if err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	routes, err := readRoutes(paths, *namespace)
+	if err != nil {
+		return err
+	}
+
+	// Then, we lint the routes and report the findings
+	findings := lint.Lint(routes, lint.Options{
+		Selector:     switchboard.NewSelector(config.Selector.IngressClass),
+		Integrations: names,
+	})
+	if err := lint.Write(stdout, *format, findings); err != nil {
+		return err
+	}
+	if lint.HasErrors(findings) {
+		return errLintFailed
+	}
+	return nil
+}
+
+func readRoutes(paths []string, namespace string) ([]lint.Route, error) {
+	scheme := runtime.NewScheme()
+	if err := traefik.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !entry.IsDir() && (file == path ||
+				slices.Contains([]string{".yaml", ".yml", ".json"}, filepath.Ext(file))) {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list manifests: %w", err)
+		}
+	}
+
+	var result []lint.Route
+	for _, file := range files {
+		reader, err := os.Open(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open manifest: %w", err)
+		}
+		objects, err := k8s.DecodeObjects(reader, scheme, k8s.WithSkipUnknownKinds())
+		_ = reader.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifest %q: %w", file, err)
+		}
+		for _, obj := range objects {
+			if route, ok := obj.(*traefik.IngressRoute); ok {
+				if route.Namespace == "" {
+					route.Namespace = namespace
+				}
+				result = append(result, lint.Route{File: file, Route: route})
+			}
+		}
+	}
+	return result, nil
+}
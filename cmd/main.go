@@ -1,15 +1,23 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"log/slog"
 	"os"
 
+	switchboardv1alpha1 "github.com/borchero/switchboard/internal/api/v1alpha1"
 	configv1 "github.com/borchero/switchboard/internal/config/v1"
 	"github.com/borchero/switchboard/internal/controllers"
+	"github.com/borchero/switchboard/internal/tracing"
 	certmanager "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	"github.com/go-logr/logr"
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	traefik "github.com/traefik/traefik/v3/pkg/provider/kubernetes/crd/traefikio/v1alpha1"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -17,11 +25,19 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	externaldnsv1alpha1 "sigs.k8s.io/external-dns/apis/v1alpha1"
 	"sigs.k8s.io/yaml"
 )
 
+const defaultAdmissionPort = 9443
+
 func main() {
+	// Run subcommands if requested
+	if len(os.Args) > 1 && (os.Args[1] == "render" || os.Args[1] == "lint") {
+		os.Exit(runSubcommand(os.Args[1], os.Args[2:]))
+	}
+
 	var cfgFile string
 	flag.StringVar(&cfgFile, "config", "/etc/switchboard/config.yaml", "The config file to use.")
 	flag.Parse()
@@ -34,17 +50,10 @@ func main() {
 	ctrl.SetLogger(logr.FromSlogHandler(logger.Handler()))
 
 	// Load the config file if available
-	var config configv1.Config
-	if cfgFile != "" {
-		contents, err := os.ReadFile(cfgFile)
-		if err != nil {
-			logger.Error("failed to read config file", "error", err)
-			os.Exit(1)
-		}
-		if err := yaml.Unmarshal(contents, &config); err != nil {
-			logger.Error("failed to parse config file", "error", err)
-			os.Exit(1)
-		}
+	config, err := loadConfig(cfgFile)
+	if err != nil {
+		logger.Error("failed to load config file", "error", err)
+		os.Exit(1)
 	}
 
 	// Initialize the options and the schema
@@ -57,6 +66,17 @@ func main() {
 			BindAddress: config.Metrics.BindAddress,
 		},
 		HealthProbeBindAddress: config.Health.HealthProbeBindAddress,
+		Client:                 controllers.ClientOptions(),
+	}
+	if config.Admission != nil {
+		port := config.Admission.Port
+		if port == 0 {
+			port = defaultAdmissionPort
+		}
+		options.WebhookServer = webhook.NewServer(webhook.Options{
+			Port:    port,
+			CertDir: config.Admission.CertDir,
+		})
 	}
 	initScheme(config, options.Scheme)
 
@@ -67,8 +87,20 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Initialize tracing if configured
+	client := manager.GetClient()
+	provider, err := initTracing(config.Tracing)
+	if err != nil {
+		logger.Error("unable to initialize tracing", "error", err)
+		os.Exit(1)
+	}
+	if provider != nil {
+		otel.SetTracerProvider(provider)
+		client = tracing.NewClient(client, provider)
+	}
+
 	// Create the controllers
-	controller, err := controllers.NewIngressRouteReconciler(manager.GetClient(), logger, config)
+	controller, err := controllers.NewIngressRouteReconciler(client, logger, config)
 	if err != nil {
 		logger.Error("unable to initialize ingress route controller", "error", err)
 		os.Exit(1)
@@ -78,6 +110,19 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Create the admission webhook if configured
+	if config.Admission != nil {
+		validator, err := controllers.NewIngressRouteValidator(client, logger, config)
+		if err != nil {
+			logger.Error("unable to initialize ingress route validator", "error", err)
+			os.Exit(1)
+		}
+		if err := validator.SetupWithManager(manager); err != nil {
+			logger.Error("unable to start ingress route validator", "error", err)
+			os.Exit(1)
+		}
+	}
+
 	// Add health check endpoints
 	if err := manager.AddReadyzCheck("readyz", healthz.Ping); err != nil {
 		logger.Error("unable to set up ready check at /readyz", "error", err)
@@ -94,13 +139,71 @@ func main() {
 		logger.Error("failed to run manager", "error", err)
 		os.Exit(1)
 	}
+	if provider != nil {
+		if err := provider.Shutdown(context.Background()); err != nil {
+			logger.Error("failed to flush spans", "error", err)
+		}
+	}
 	logger.Info("gracefully shut down")
 }
 
+func runSubcommand(name string, args []string) int {
+	var err error
+	switch name {
+	case "render":
+		err = runRender(args, os.Stdin, os.Stdout)
+	case "lint":
+		err = runLint(args, os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		return 1
+	}
+	return 0
+}
+
+func loadConfig(cfgFile string) (configv1.Config, error) {
+	var config configv1.Config
+	if cfgFile == "" {
+		return config, nil
+	}
+	contents, err := os.ReadFile(cfgFile)
+	if err != nil {
+		return config, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err := yaml.Unmarshal(contents, &config); err != nil {
+		return config, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	return config, nil
+}
+
+func initTracing(config *configv1.TracingConfig) (*sdktrace.TracerProvider, error) {
+	if config == nil {
+		return nil, nil
+	}
+	if config.Endpoint == "" {
+		return nil, fmt.Errorf("endpoint must be set")
+	}
+	samplingRatio := 1.0
+	if config.SamplingRatio != nil {
+		samplingRatio = *config.SamplingRatio
+	}
+	return tracing.NewProvider(context.Background(), tracing.Options{
+		Endpoint:      config.Endpoint,
+		Protocol:      config.Protocol,
+		Insecure:      config.Insecure,
+		SamplingRatio: samplingRatio,
+	})
+}
+
 func initScheme(config configv1.Config, scheme *runtime.Scheme) {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(traefik.AddToScheme(scheme))
 
+	if config.RouteStatus.Enabled {
+		utilruntime.Must(switchboardv1alpha1.AddToScheme(scheme))
+	}
+
 	if config.Integrations.CertManager != nil {
 		utilruntime.Must(certmanager.AddToScheme(scheme))
 	}
@@ -108,4 +211,23 @@ func initScheme(config configv1.Config, scheme *runtime.Scheme) {
 	if config.Integrations.ExternalDNS != nil {
 		utilruntime.Must(externaldnsv1alpha1.AddToScheme(scheme))
 	}
+
+	if config.Integrations.Probe != nil {
+		utilruntime.Must(monitoringv1.AddToScheme(scheme))
+	}
+
+	// Resources created from templates are handled as unstructured objects
+	for _, tmpl := range config.Integrations.Templates {
+		gvk, err := tmpl.GroupVersionKind()
+		if err != nil {
+			// Invalid kinds are reported when initializing the integrations
+			continue
+		}
+		if !scheme.Recognizes(gvk) {
+			scheme.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+			scheme.AddKnownTypeWithName(
+				gvk.GroupVersion().WithKind(gvk.Kind+"List"), &unstructured.UnstructuredList{},
+			)
+		}
+	}
 }
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/borchero/switchboard/internal/controllers"
+	"github.com/borchero/switchboard/internal/k8s"
+	traefik "github.com/traefik/traefik/v3/pkg/provider/kubernetes/crd/traefikio/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+const renderUsage = `Usage: switchboard render [flags] [file...]
+
+Prints the resources that Switchboard would generate for the ingress routes in the given files (or
+standard input if no files are given) without connecting to a cluster. Services that are used to
+resolve targets may be supplied alongside the ingress routes.
+
+Flags:
+`
+
+// runRender runs the `render` subcommand with the given arguments.
+func runRender(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("render", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), renderUsage)
+		flags.PrintDefaults()
+	}
+	cfgFile := flags.String("config", "", "The config file to use.")
+	namespace := flags.String(
+		"namespace", "default", "The namespace of ingress routes and services without namespace.",
+	)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *cfgFile == "" {
+		return fmt.Errorf("the `-config` flag is required")
+	}
+
+	// First, we load the configuration along with the manifests
+	config, err := loadConfig(*cfgFile)
+	if err != nil {
+		return err
+	}
+	scheme := runtime.NewScheme()
+	initScheme(config, scheme)
+	objects, err := readManifests(flags.Args(), stdin, scheme)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objects {
+		switch obj.(type) {
+		case *traefik.IngressRoute, *v1.Service:
+			if obj.GetNamespace() == "" {
+				obj.SetNamespace(*namespace)
+			}
+		}
+	}
+
+	// Then, we can render the resources and print them
+	resources, err := controllers.Render(context.Background(), config, scheme, objects)
+	if err != nil {
+		return err
+	}
+	for i, resource := range resources {
+		contents, err := yaml.Marshal(resource)
+		if err != nil {
+			return fmt.Errorf("failed to serialize %s: %s", resource.GetName(), err)
+		}
+		if i > 0 {
+			fmt.Fprintln(stdout, "---")
+		}
+		if _, err := stdout.Write(contents); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readManifests(
+	files []string, stdin io.Reader, scheme *runtime.Scheme,
+) ([]client.Object, error) {
+	if len(files) == 0 {
+		return k8s.DecodeObjects(stdin, scheme)
+	}
+	var result []client.Object
+	for _, file := range files {
+		reader, err := os.Open(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open manifest: %w", err)
+		}
+		objects, err := k8s.DecodeObjects(reader, scheme)
+		_ = reader.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifest %q: %w", file, err)
+		}
+		result = append(result, objects...)
+	}
+	return result, nil
+}
@@ -0,0 +1,76 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// IntegrationState describes the outcome of running an integration for an ingress route.
+type IntegrationState string
+
+const (
+	// IntegrationStateReady indicates that the integration ran successfully.
+	IntegrationStateReady IntegrationState = "Ready"
+	// IntegrationStateError indicates that the integration failed.
+	IntegrationStateError IntegrationState = "Error"
+	// IntegrationStateIgnored indicates that the integration is ignored for the ingress route.
+	IntegrationStateIgnored IntegrationState = "Ignored"
+	// IntegrationStateWaiting indicates that the integration waits for its dependencies.
+	IntegrationStateWaiting IntegrationState = "Waiting"
+)
+
+// ResourceReference references a resource that was generated for an ingress route.
+type ResourceReference struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+}
+
+// IntegrationStatus describes the outcome of running a single integration for an ingress route.
+type IntegrationStatus struct {
+	// Name is the name of the integration.
+	Name string `json:"name"`
+	// State is the outcome of running the integration.
+	State IntegrationState `json:"state"`
+	// Message provides details about the state, e.g. the error.
+	Message string `json:"message,omitempty"`
+	// Resources are the resources that the integration manages for the ingress route.
+	Resources []ResourceReference `json:"resources,omitempty"`
+}
+
+// RouteStatusStatus describes what Switchboard did for an ingress route.
+type RouteStatusStatus struct {
+	// ObservedGeneration is the generation of the ingress route that was processed last.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Hosts are the effective hosts of the ingress route.
+	Hosts []string `json:"hosts,omitempty"`
+	// Targets are the resolved targets that the hosts point to.
+	Targets []string `json:"targets,omitempty"`
+	// Integrations describe the outcome of all integrations.
+	Integrations []IntegrationStatus `json:"integrations,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+
+// RouteStatus reports the state of an ingress route processed by Switchboard. It has the same name
+// as the ingress route and is owned by it.
+type RouteStatus struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Status RouteStatusStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// RouteStatusList contains a list of RouteStatus.
+type RouteStatusList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RouteStatus `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RouteStatus{}, &RouteStatusList{})
+}
@@ -0,0 +1,75 @@
+package cloudevents
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	specVersion   = "1.0"
+	clientTimeout = 10 * time.Second
+)
+
+// Event is a CloudEvent whose data is serialized as JSON.
+type Event struct {
+	ID      string
+	Source  string
+	Type    string
+	Subject string
+	Time    time.Time
+	Data    any
+}
+
+// Client sends CloudEvents to a sink via HTTP in binary content mode, i.e. the event attributes
+// are sent as `ce-` headers and the data is sent as request body.
+type Client struct {
+	sinkURL    string
+	httpClient *http.Client
+}
+
+// NewClient initializes a new client sending events to the given sink URL.
+func NewClient(sinkURL string) *Client {
+	return &Client{
+		sinkURL:    sinkURL,
+		httpClient: &http.Client{Timeout: clientTimeout},
+	}
+}
+
+// Send sends the given event to the sink and returns an error if the sink does not accept it.
+func (c *Client) Send(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event.Data)
+	if err != nil {
+		return fmt.Errorf("failed to serialize event data: %s", err)
+	}
+	request, err := http.NewRequestWithContext(
+		ctx, http.MethodPost, c.sinkURL, bytes.NewReader(body),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %s", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("ce-specversion", specVersion)
+	request.Header.Set("ce-id", event.ID)
+	request.Header.Set("ce-source", event.Source)
+	request.Header.Set("ce-type", event.Type)
+	if event.Subject != "" {
+		request.Header.Set("ce-subject", event.Subject)
+	}
+	if !event.Time.IsZero() {
+		request.Header.Set("ce-time", event.Time.UTC().Format(time.RFC3339Nano))
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to send event: %w", err)
+	}
+	defer response.Body.Close() // nolint:errcheck
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("sink responded with status %d", response.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package cloudevents
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientSend(t *testing.T) {
+	var headers http.Header
+	var body []byte
+	status := http.StatusAccepted
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	// Attributes should be sent as headers and data as body
+	client := NewClient(server.URL)
+	err := client.Send(context.Background(), Event{
+		ID:      "1234",
+		Source:  "/switchboard",
+		Type:    "com.example.test",
+		Subject: "my-subject",
+		Time:    time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		Data:    map[string]string{"key": "value"},
+	})
+	require.Nil(t, err)
+	assert.Equal(t, "1.0", headers.Get("ce-specversion"))
+	assert.Equal(t, "1234", headers.Get("ce-id"))
+	assert.Equal(t, "/switchboard", headers.Get("ce-source"))
+	assert.Equal(t, "com.example.test", headers.Get("ce-type"))
+	assert.Equal(t, "my-subject", headers.Get("ce-subject"))
+	assert.Equal(t, "2026-01-01T00:00:00Z", headers.Get("ce-time"))
+	assert.Equal(t, "application/json", headers.Get("Content-Type"))
+	assert.JSONEq(t, `{"key": "value"}`, string(body))
+
+	// Rejected events should result in an error
+	status = http.StatusBadRequest
+	err = client.Send(context.Background(), Event{ID: "1234"})
+	assert.NotNil(t, err)
+}
@@ -1,7 +1,13 @@
 package v1
 
 import (
+	"fmt"
+
 	v1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	traefik "github.com/traefik/traefik/v3/pkg/provider/kubernetes/crd/traefikio/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 // Config is the Schema for the configs API
@@ -9,6 +15,10 @@ type Config struct {
 	ControllerConfig `json:",inline"`
 	Selector         IngressSelector    `json:"selector"`
 	Integrations     IntegrationConfigs `json:"integrations"`
+	CloudEvents      *CloudEventsConfig `json:"cloudEvents,omitempty"`
+	Tracing          *TracingConfig     `json:"tracing,omitempty"`
+	RouteStatus      RouteStatusConfig  `json:"routeStatus,omitempty"`
+	Admission        *AdmissionConfig   `json:"admission,omitempty"`
 }
 
 //-------------------------------------------------------------------------------------------------
@@ -18,6 +28,15 @@ type ControllerConfig struct {
 	Health         HealthConfig         `json:"health,omitempty"`
 	LeaderElection LeaderElectionConfig `json:"leaderElection,omitempty"`
 	Metrics        MetricsConfig        `json:"metrics,omitempty"`
+	// The maximum number of integrations that are run concurrently for a single ingress route.
+	// Defaults to 1, i.e. integrations are run sequentially.
+	IntegrationConcurrency int `json:"integrationConcurrency,omitempty"`
+	// The maximum time that integrations wait for their dependencies to become ready before they
+	// are run regardless. Defaults to 10 minutes.
+	DependencyTimeout *metav1.Duration `json:"dependencyTimeout,omitempty"`
+	// Whether the controller should only compute and log the changes that integrations would make
+	// without writing to Kubernetes or any other system.
+	DryRun bool `json:"dryRun,omitempty"`
 }
 
 // HealthConfig provides configuration for the controller health checks.
@@ -39,6 +58,43 @@ type MetricsConfig struct {
 
 //-------------------------------------------------------------------------------------------------
 
+// CloudEventsConfig describes the sink that CloudEvents are sent to whenever an integration
+// creates, updates or deletes a resource. The source of the events defaults to `switchboard`.
+type CloudEventsConfig struct {
+	SinkURL string `json:"sinkURL"`
+	Source  string `json:"source,omitempty"`
+}
+
+// TracingConfig describes the OTLP receiver that spans of reconciliations are exported to. The
+// protocol is one of `grpc` (default) and `http`. The sampling ratio defaults to 1, i.e. all
+// reconciliations are traced.
+type TracingConfig struct {
+	Endpoint      string   `json:"endpoint"`
+	Protocol      string   `json:"protocol,omitempty"`
+	Insecure      bool     `json:"insecure,omitempty"`
+	SamplingRatio *float64 `json:"samplingRatio,omitempty"`
+}
+
+// RouteStatusConfig describes whether a `RouteStatus` resource reporting the outcome of the
+// integrations is maintained for every processed ingress route. Requires the `RouteStatus` CRD.
+type RouteStatusConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+}
+
+// AdmissionConfig describes the validating admission webhook for ingress routes which is served
+// by the manager on the given port (defaults to 9443) using the certificate in the given
+// directory. The mode is one of `deny` (default), which rejects ingress routes that would fail to
+// be processed, and `warn`, which only returns admission warnings. If the webhook is configured to
+// fail open, ingress routes are admitted whenever they cannot be validated.
+type AdmissionConfig struct {
+	Port     int    `json:"port,omitempty"`
+	CertDir  string `json:"certDir,omitempty"`
+	Mode     string `json:"mode,omitempty"`
+	FailOpen bool   `json:"failOpen,omitempty"`
+}
+
+//-------------------------------------------------------------------------------------------------
+
 // IngressSelector can be used to limit operations to ingresses with a specific class.
 type IngressSelector struct {
 	IngressClass *string `json:"ingressClass,omitempty"`
@@ -48,23 +104,193 @@ type IngressSelector struct {
 type IntegrationConfigs struct {
 	ExternalDNS *ExternalDNSIntegrationConfig `json:"externalDNS"`
 	CertManager *CertManagerIntegrationConfig `json:"certManager"`
+	RFC2136     *RFC2136IntegrationConfig     `json:"rfc2136"`
+	CoreDNS     *CoreDNSIntegrationConfig     `json:"coreDNS"`
+	Redirect    *RedirectIntegrationConfig    `json:"redirect"`
+	TLSStore    *TLSStoreIntegrationConfig    `json:"tlsStore"`
+	Probe       *ProbeIntegrationConfig       `json:"probe"`
+	Reflector   *ReflectorIntegrationConfig   `json:"reflector"`
+	Webhook     *WebhookIntegrationConfig     `json:"webhook"`
+	Templates   []TemplateIntegrationConfig   `json:"templates,omitempty"`
 }
 
 // ExternalDNSIntegrationConfig describes the configuration for the external-dns integration.
-// Exactly one of target and target IPs should be set.
+//
+// The CAA records map cert-manager issuers, identified by `<kind>/<name>` (e.g.
+// `ClusterIssuer/letsencrypt`), to CAA record values (e.g. `0 issue "letsencrypt.org"`). If the
+// cert-manager integration is enabled and the issuer of its certificate template is found in the
+// mapping, CAA records are published for all hosts of ingresses for which the cert-manager
+// integration requests a TLS certificate.
 type ExternalDNSIntegrationConfig struct {
-	TargetService *ServiceRef `json:"targetService,omitempty"`
-	TargetIPs     []string    `json:"targetIPs,omitempty"`
-	TTL           *int64      `json:"ttl,omitempty"`
+	TargetConfig `json:",inline"`
+	TTL          *int64              `json:"ttl,omitempty"`
+	CAARecords   map[string][]string `json:"caaRecords,omitempty"`
+	HTTPSRecords *HTTPSRecordsConfig `json:"httpsRecords,omitempty"`
+	ExtraRecords *ExtraRecordsConfig `json:"extraRecords,omitempty"`
+}
+
+// RFC2136IntegrationConfig describes the configuration for the RFC2136 integration which manages
+// DNS records via dynamic updates. The TSIG secret is read from the environment variable with the
+// given name (if set) to prevent storing it in the config file. The owner ID is used to identify
+// the records managed by this instance of Switchboard and defaults to `default`.
+type RFC2136IntegrationConfig struct {
+	TargetConfig  `json:",inline"`
+	Address       string `json:"address"`
+	Zone          string `json:"zone"`
+	TSIGKeyName   string `json:"tsigKeyName,omitempty"`
+	TSIGSecretEnv string `json:"tsigSecretEnv,omitempty"`
+	TSIGAlgorithm string `json:"tsigAlgorithm,omitempty"`
+	OwnerID       string `json:"ownerID,omitempty"`
+	TTL           *int64 `json:"ttl,omitempty"`
+}
+
+// CoreDNSIntegrationConfig describes the configuration for the CoreDNS integration which resolves
+// hosts to the cluster IPs of the target service(s) from within the cluster. The server block
+// for CoreDNS is written to the given config map which defaults to `kube-system/coredns-custom`.
+type CoreDNSIntegrationConfig struct {
+	TargetService  *ServiceRef   `json:"targetService,omitempty"`
+	TargetServices []ServiceRef  `json:"targetServices,omitempty"`
+	ConfigMap      *ConfigMapRef `json:"configMap,omitempty"`
+	TTL            *int64        `json:"ttl,omitempty"`
+}
+
+// RedirectIntegrationConfig describes the configuration for the redirect integration which creates
+// companion ingress routes redirecting plain HTTP traffic to HTTPS. The entry point defaults to
+// `web` and the middleware (typically a `redirectScheme` middleware) must be set. If the
+// middleware's namespace is not set, it is resolved in the namespace of the ingress route.
+type RedirectIntegrationConfig struct {
+	EntryPoint string        `json:"entryPoint,omitempty"`
+	Middleware MiddlewareRef `json:"middleware"`
+}
+
+// TLSStoreIntegrationConfig describes the configuration for the TLS store integration which
+// registers the TLS secrets that cert-manager issues for ingress routes in the `default` Traefik
+// TLS store. As Traefik only supports a single TLS store, the namespace of the TLS store must be
+// set and only ingress routes in this namespace are registered. The mode must be one of `none`
+// (default), `certificate` and `default` and is used for all ingress routes that do not set the
+// `switchboard.borchero.com/tls-store` annotation. The options map names of TLS option profiles to
+// Traefik `TLSOption` specs: ingress routes referencing one of the profiles via
+// `spec.tls.options` are bound to a `TLSOption` with the profile's spec in their namespace.
+type TLSStoreIntegrationConfig struct {
+	Namespace string                           `json:"namespace"`
+	Mode      string                           `json:"mode,omitempty"`
+	Options   map[string]traefik.TLSOptionSpec `json:"options,omitempty"`
+}
+
+// ProbeIntegrationConfig describes the configuration for the probe integration which creates
+// prometheus-operator probes for all hosts of ingress routes. The prober URL is the address of the
+// blackbox exporter and the module defaults to `http_2xx`.
+type ProbeIntegrationConfig struct {
+	ProberURL string            `json:"proberURL"`
+	Module    string            `json:"module,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// ReflectorIntegrationConfig describes the configuration for the reflector integration which
+// copies TLS secrets into other namespaces. Ingress routes may only request copies in namespaces
+// that match one of the allowed namespace patterns (e.g. `tenant-*`).
+type ReflectorIntegrationConfig struct {
+	AllowedNamespaces []string `json:"allowedNamespaces"`
+}
+
+// WebhookIntegrationConfig describes the configuration for the webhook integration which sends
+// JSON descriptions of ingress routes to the given URL. The HMAC secret used to sign payloads is
+// read from the environment variable with the given name (if set). Targets are optional and only
+// included in the payloads if set. The hashes of delivered payloads are stored in the given config
+// map whose name defaults to `switchboard-webhook`.
+type WebhookIntegrationConfig struct {
+	TargetConfig `json:",inline"`
+	URL          string       `json:"url"`
+	SecretEnv    string       `json:"secretEnv,omitempty"`
+	MaxRetries   *int         `json:"maxRetries,omitempty"`
+	State        ConfigMapRef `json:"state"`
+}
+
+// TemplateIntegrationConfig describes a template integration which creates a resource of the given
+// kind for every ingress route by rendering a Go template. The name identifies the integration,
+// e.g. for the `switchboard.borchero.com/ignore` annotation, and must be unique.
+type TemplateIntegrationConfig struct {
+	Name       string `json:"name"`
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Template   string `json:"template"`
+}
+
+// GroupVersionKind returns the kind of the resources created by the template integration.
+func (c TemplateIntegrationConfig) GroupVersionKind() (schema.GroupVersionKind, error) {
+	if c.APIVersion == "" || c.Kind == "" {
+		return schema.GroupVersionKind{}, fmt.Errorf("`apiVersion` and `kind` must be set")
+	}
+	gv, err := schema.ParseGroupVersion(c.APIVersion)
+	if err != nil {
+		return schema.GroupVersionKind{}, err
+	}
+	return gv.WithKind(c.Kind), nil
+}
+
+// MiddlewareRef describes a Traefik middleware.
+type MiddlewareRef struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// ConfigMapRef uniquely describes a Kubernetes config map.
+type ConfigMapRef struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+// TargetConfig describes the targets that DNS records point to. Exactly one of target service(s),
+// target IPs and target nodes should be set. Target service and target services may be combined,
+// in which case the targets of all services are merged.
+type TargetConfig struct {
+	TargetService  *ServiceRef         `json:"targetService,omitempty"`
+	TargetServices []ServiceRef        `json:"targetServices,omitempty"`
+	TargetIPs      []string            `json:"targetIPs,omitempty"`
+	TargetNodes    *NodeTargetConfig   `json:"targetNodes,omitempty"`
+	TargetFilter   *TargetFilterConfig `json:"targetFilter,omitempty"`
+}
+
+// ExtraRecordsConfig describes which additional DNS records ingresses may request via the
+// `switchboard.borchero.com/dns-records` annotation.
+type ExtraRecordsConfig struct {
+	AllowedTypes []string `json:"allowedTypes"`
+}
+
+// HTTPSRecordsConfig describes the HTTPS records that are published to advertise HTTP/3 support.
+// Records are published for ingresses bound to any of the given HTTP/3-capable entry points.
+type HTTPSRecordsConfig struct {
+	EntryPoints []string `json:"entryPoints"`
+	ALPN        []string `json:"alpn,omitempty"`
+}
+
+// TargetFilterConfig describes how the resolved targets are filtered before being published. The
+// IP family policy must be one of `DualStack` (default), `IPv4Only`, `IPv6Only` and `PreferIPv4`.
+type TargetFilterConfig struct {
+	IPFamilyPolicy string   `json:"ipFamilyPolicy,omitempty"`
+	AllowCIDRs     []string `json:"allowCIDRs,omitempty"`
+	DenyCIDRs      []string `json:"denyCIDRs,omitempty"`
+}
+
+// NodeTargetConfig describes the nodes whose addresses are used as targets. Address types are
+// tried in order for each node and default to external IPs with a fallback to internal IPs.
+type NodeTargetConfig struct {
+	Selector     metav1.LabelSelector     `json:"selector,omitempty"`
+	AddressTypes []corev1.NodeAddressType `json:"addressTypes,omitempty"`
 }
 
 // CertManagerIntegrationConfig describes the configuration for the cert-manager integration.
+// Certificates are only created once the integrations listed in `dependsOn` (e.g.
+// `external-dns`) are ready, such that HTTP-01 challenges do not fail due to missing DNS records.
 type CertManagerIntegrationConfig struct {
-	Template v1.Certificate `json:"certificateTemplate"`
+	Template  v1.Certificate `json:"certificateTemplate"`
+	DependsOn []string       `json:"dependsOn,omitempty"`
 }
 
-// ServiceRef uniquely describes a Kubernetes service.
+// ServiceRef uniquely describes a Kubernetes service. Alternatively, it describes all services in
+// the namespace matching a label selector if the selector is set instead of the name.
 type ServiceRef struct {
-	Name      string `json:"name"`
-	Namespace string `json:"namespace"`
+	Name      string                `json:"name,omitempty"`
+	Namespace string                `json:"namespace"`
+	Selector  *metav1.LabelSelector `json:"selector,omitempty"`
 }
@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	configv1 "github.com/borchero/switchboard/internal/config/v1"
+	"github.com/borchero/switchboard/internal/integrations"
+	"github.com/borchero/switchboard/internal/lint"
+	"github.com/borchero/switchboard/internal/switchboard"
+	traefik "github.com/traefik/traefik/v3/pkg/provider/kubernetes/crd/traefikio/v1alpha1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	admissionModeDeny = "deny"
+	admissionModeWarn = "warn"
+)
+
+// IngressRouteValidator validates ingress routes upon admission. It rejects ingress routes whose
+// hosts cannot be extracted, which conflict with other ingress routes or which are rejected by
+// any of the integrations.
+type IngressRouteValidator struct {
+	client.Reader
+	logger           *slog.Logger
+	selector         switchboard.Selector
+	integrations     []integrations.Integration
+	integrationNames []string
+	warnOnly         bool
+	failOpen         bool
+}
+
+// NewIngressRouteValidator creates a new IngressRouteValidator.
+func NewIngressRouteValidator(
+	client client.Client, logger *slog.Logger, config configv1.Config,
+) (IngressRouteValidator, error) {
+	var admissionConfig configv1.AdmissionConfig
+	if config.Admission != nil {
+		admissionConfig = *config.Admission
+	}
+	switch admissionConfig.Mode {
+	case "", admissionModeDeny, admissionModeWarn:
+	default:
+		return IngressRouteValidator{}, fmt.Errorf(
+			"invalid admission mode %q, must be one of %s and %s",
+			admissionConfig.Mode, admissionModeDeny, admissionModeWarn,
+		)
+	}
+	integrations, err := integrationsFromConfig(config, client)
+	if err != nil {
+		return IngressRouteValidator{}, fmt.Errorf("failed to initialize integrations: %s", err)
+	}
+	names := make([]string, 0, len(integrations))
+	for _, itg := range integrations {
+		names = append(names, itg.Name())
+	}
+	return IngressRouteValidator{
+		Reader:           client,
+		logger:           logger,
+		selector:         switchboard.NewSelector(config.Selector.IngressClass),
+		integrations:     integrations,
+		integrationNames: names,
+		warnOnly:         admissionConfig.Mode == admissionModeWarn,
+		failOpen:         admissionConfig.FailOpen,
+	}, nil
+}
+
+// ValidateCreate validates an ingress route that is about to be created.
+func (v *IngressRouteValidator) ValidateCreate(
+	ctx context.Context, route *traefik.IngressRoute,
+) (admission.Warnings, error) {
+	return v.validate(ctx, route)
+}
+
+// ValidateUpdate validates an ingress route that is about to be updated.
+func (v *IngressRouteValidator) ValidateUpdate(
+	ctx context.Context, _, route *traefik.IngressRoute,
+) (admission.Warnings, error) {
+	return v.validate(ctx, route)
+}
+
+// ValidateDelete admits all deletions of ingress routes.
+func (*IngressRouteValidator) ValidateDelete(
+	context.Context, *traefik.IngressRoute,
+) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// SetupWithManager registers the webhook with the Manager.
+func (v *IngressRouteValidator) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, &traefik.IngressRoute{}).WithValidator(v).Complete()
+}
+
+func (v *IngressRouteValidator) validate(
+	ctx context.Context, route *traefik.IngressRoute,
+) (admission.Warnings, error) {
+	// Routes which are not processed by the controller cannot fail
+	if !v.selector.Matches(route.Annotations) {
+		return nil, nil
+	}
+
+	logger := v.logger.With("name", route.Namespace+"/"+route.Name)
+	problems, warnings, err := v.check(ctx, route)
+	if err != nil {
+		if !v.failOpen {
+			logger.Error("failed to validate ingress route", "error", err)
+			return nil, fmt.Errorf("failed to validate ingress route: %w", err)
+		}
+		logger.Error("failed to validate ingress route, admitting it", "error", err)
+		return admission.Warnings{
+			fmt.Sprintf("ingress route could not be validated by switchboard: %s", err),
+		}, nil
+	}
+	if len(problems) == 0 {
+		return warnings, nil
+	}
+	if v.warnOnly {
+		logger.Info("admitting invalid ingress route", "problems", problems)
+		return append(warnings, problems...), nil
+	}
+	logger.Info("denying invalid ingress route", "problems", problems)
+	return warnings, fmt.Errorf("ingress route is invalid: %s", strings.Join(problems, "; "))
+}
+
+// check returns the problems that prevent the ingress route from being processed along with any
+// warnings. An error is only returned if the ingress route cannot be validated.
+func (v *IngressRouteValidator) check(
+	ctx context.Context, route *traefik.IngressRoute,
+) ([]string, []string, error) {
+	// First, we extract the hosts just like the reconciler does
+	info, err := ingressInfo(route)
+	if err != nil {
+		return []string{fmt.Sprintf("failed to parse hosts: %s", err)}, nil, nil
+	}
+
+	// Then, we check for conflicts with all other ingress routes in the cluster
+	var list traefik.IngressRouteList
+	if err := v.List(ctx, &list); err != nil {
+		return nil, nil, fmt.Errorf("failed to list ingress routes: %w", err)
+	}
+	routes := []lint.Route{{Route: route}}
+	for i := range list.Items {
+		other := &list.Items[i]
+		if other.Namespace != route.Namespace || other.Name != route.Name {
+			routes = append(routes, lint.Route{Route: other})
+		}
+	}
+	var problems, warnings []string
+	findings := lint.Lint(routes, lint.Options{
+		Selector:     v.selector,
+		Integrations: v.integrationNames,
+	})
+	for _, finding := range findings {
+		if finding.Namespace != route.Namespace || finding.Name != route.Name {
+			continue
+		}
+		message := fmt.Sprintf("%s (%s)", finding.Message, finding.Rule)
+		if finding.Severity == lint.SeverityError {
+			problems = append(problems, message)
+		} else {
+			warnings = append(warnings, message)
+		}
+	}
+
+	// Eventually, we ask the integrations whether they would accept the ingress route
+	for _, itg := range v.integrations {
+		validator, ok := itg.(integrations.Validator)
+		if !ok || !v.selector.MatchesIntegration(route.Annotations, itg.Name()) {
+			continue
+		}
+		if err := validator.Validate(ctx, route, info); err != nil {
+			problems = append(problems, fmt.Sprintf("integration %s: %s", itg.Name(), err))
+		}
+	}
+	return problems, warnings, nil
+}
@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	configv1 "github.com/borchero/switchboard/internal/config/v1"
+	"github.com/borchero/switchboard/internal/k8tests"
+	"github.com/borchero/switchboard/internal/switchboard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	traefik "github.com/traefik/traefik/v3/pkg/provider/kubernetes/crd/traefikio/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestIngressRouteValidator(t *testing.T) {
+	ctx := context.Background()
+	existing := admissionRoute("existing", "www.example.com", "www-tls")
+	c := fake.NewClientBuilder().WithScheme(k8tests.NewScheme()).WithObjects(existing).Build()
+
+	var config configv1.Config
+	config.Integrations.CertManager = &configv1.CertManagerIntegrationConfig{}
+	config.Integrations.Reflector = &configv1.ReflectorIntegrationConfig{
+		AllowedNamespaces: []string{"tenant-*"},
+	}
+	validator, err := NewIngressRouteValidator(c, slog.Default(), config)
+	require.Nil(t, err)
+
+	// Valid routes should be admitted
+	route := admissionRoute("my-route", "api.example.com", "api-tls")
+	warnings, err := validator.ValidateCreate(ctx, route)
+	assert.Nil(t, err)
+	assert.Empty(t, warnings)
+
+	// Updates of the existing route should not conflict with the route itself
+	warnings, err = validator.ValidateUpdate(ctx, existing, existing)
+	assert.Nil(t, err)
+	assert.Empty(t, warnings)
+
+	// Host conflicts should only result in warnings
+	route = admissionRoute("my-route", "www.example.com", "api-tls")
+	warnings, err = validator.ValidateCreate(ctx, route)
+	assert.Nil(t, err)
+	assert.Len(t, warnings, 1)
+
+	// TLS secret collisions and unparseable rules should be denied
+	route = admissionRoute("my-route", "api.example.com", "www-tls")
+	_, err = validator.ValidateCreate(ctx, route)
+	assert.ErrorContains(t, err, "tls-secret-collision")
+
+	// ...unless cert-manager does not issue a certificate for the route
+	route.Annotations = map[string]string{"switchboard.borchero.com/ignore": "cert-manager"}
+	_, err = validator.ValidateCreate(ctx, route)
+	assert.Nil(t, err)
+
+	route = admissionRoute("my-route", "api.example.com", "api-tls")
+	route.Spec.Routes[0].Match = "Host(`api.example.com`"
+	_, err = validator.ValidateCreate(ctx, route)
+	assert.ErrorContains(t, err, "failed to parse hosts")
+
+	// Integrations should be able to reject routes
+	route = admissionRoute("my-route", "api.example.com", "api-tls")
+	route.Annotations = map[string]string{
+		"switchboard.borchero.com/reflect-tls-to": "kube-system",
+	}
+	_, err = validator.ValidateCreate(ctx, route)
+	assert.ErrorContains(t, err, "integration reflector")
+
+	// ...unless they are ignored for the route
+	route.Annotations["switchboard.borchero.com/ignore"] = "reflector"
+	_, err = validator.ValidateCreate(ctx, route)
+	assert.Nil(t, err)
+
+	// Routes of other ingress classes should not be validated
+	route.Annotations["kubernetes.io/ingress.class"] = "other"
+	route.Spec.Routes[0].Match = "Host(`api.example.com`"
+	ingressClass := "traefik"
+	validator.selector = switchboard.NewSelector(&ingressClass)
+	_, err = validator.ValidateCreate(ctx, route)
+	assert.Nil(t, err)
+}
+
+func TestIngressRouteValidatorModes(t *testing.T) {
+	ctx := context.Background()
+	failing := fake.NewClientBuilder().
+		WithScheme(k8tests.NewScheme()).
+		WithInterceptorFuncs(interceptor.Funcs{
+			List: func(
+				context.Context, client.WithWatch, client.ObjectList, ...client.ListOption,
+			) error {
+				return errors.New("unavailable")
+			},
+		}).
+		Build()
+	var config configv1.Config
+
+	// Invalid modes should be rejected
+	config.Admission = &configv1.AdmissionConfig{Mode: "audit"}
+	_, err := NewIngressRouteValidator(failing, slog.Default(), config)
+	assert.NotNil(t, err)
+
+	// In warn mode, problems should only be reported as warnings
+	config.Admission = &configv1.AdmissionConfig{Mode: "warn"}
+	validator, err := NewIngressRouteValidator(failing, slog.Default(), config)
+	require.Nil(t, err)
+	route := admissionRoute("my-route", "api.example.com", "api-tls")
+	route.Spec.Routes[0].Match = "Host(`api.example.com`"
+	warnings, err := validator.ValidateCreate(ctx, route)
+	assert.Nil(t, err)
+	assert.Len(t, warnings, 1)
+
+	// Routes that cannot be validated should be denied unless the webhook fails open
+	route = admissionRoute("my-route", "api.example.com", "api-tls")
+	_, err = validator.ValidateCreate(ctx, route)
+	assert.ErrorContains(t, err, "unavailable")
+
+	config.Admission.FailOpen = true
+	validator, err = NewIngressRouteValidator(failing, slog.Default(), config)
+	require.Nil(t, err)
+	warnings, err = validator.ValidateCreate(ctx, route)
+	assert.Nil(t, err)
+	assert.Len(t, warnings, 1)
+}
+
+func admissionRoute(name, host, secretName string) *traefik.IngressRoute {
+	return &traefik.IngressRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: traefik.IngressRouteSpec{
+			Routes: []traefik.Route{{Kind: "Rule", Match: "Host(`" + host + "`)"}},
+			TLS:    &traefik.TLS{SecretName: secretName},
+		},
+	}
+}
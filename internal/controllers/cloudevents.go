@@ -0,0 +1,159 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/borchero/switchboard/internal/cloudevents"
+	configv1 "github.com/borchero/switchboard/internal/config/v1"
+	"github.com/borchero/switchboard/internal/integrations"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	changeEventTypePrefix = "com.borchero.switchboard.resource."
+	changeEventBufferSize = 1024
+)
+
+// ChangeEventData is the data of the CloudEvents that are emitted for changes of resources.
+type ChangeEventData struct {
+	Integration string            `json:"integration"`
+	Route       ObjectReference   `json:"route"`
+	Resource    ResourceReference `json:"resource"`
+	Operation   string            `json:"operation"`
+	Summary     string            `json:"summary"`
+	Diff        string            `json:"diff,omitempty"`
+	DryRun      bool              `json:"dryRun,omitempty"`
+}
+
+// ObjectReference references a namespaced object of a known kind.
+type ObjectReference struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// ResourceReference references a namespaced object of any kind.
+type ResourceReference struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+}
+
+type changeEmitter struct {
+	client *cloudevents.Client
+	events chan cloudevents.Event
+	source string
+	dryRun bool
+	scheme *runtime.Scheme
+	logger *slog.Logger
+}
+
+func changeEmitterFromConfig(
+	config *configv1.CloudEventsConfig, dryRun bool, scheme *runtime.Scheme, logger *slog.Logger,
+) (*changeEmitter, error) {
+	if config == nil {
+		return nil, nil
+	}
+	if config.SinkURL == "" {
+		return nil, fmt.Errorf("`sinkURL` must be set for cloud events")
+	}
+	if _, err := url.ParseRequestURI(config.SinkURL); err != nil {
+		return nil, fmt.Errorf("invalid sink url for cloud events: %s", err)
+	}
+	source := config.Source
+	if source == "" {
+		source = "switchboard"
+	}
+	return &changeEmitter{
+		client: cloudevents.NewClient(config.SinkURL),
+		events: make(chan cloudevents.Event, changeEventBufferSize),
+		source: source,
+		dryRun: dryRun,
+		scheme: scheme,
+		logger: logger,
+	}, nil
+}
+
+// Start sends all buffered events to the sink until the given context is cancelled. Events are
+// delivered in the background to not block reconciliation on a slow or unavailable sink.
+func (e *changeEmitter) Start(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-e.events:
+			if err := e.client.Send(ctx, event); err != nil {
+				// Failing to emit events should not fail reconciliation
+				e.logger.Error("failed to emit cloud event", "type", event.Type, "error", err)
+			}
+		}
+	}
+}
+
+// withChangeHandler returns a context which causes the integration with the given name to emit
+// CloudEvents for all changes that it makes for the given route.
+func (e *changeEmitter) withChangeHandler(
+	ctx context.Context, integration string, route types.NamespacedName,
+) context.Context {
+	if e == nil {
+		return ctx
+	}
+	return integrations.WithChangeHandler(
+		ctx, func(ctx context.Context, change integrations.Change) {
+			if change.Operation == controllerutil.OperationResultNone {
+				return
+			}
+			event := e.event(integration, route, change)
+			select {
+			case e.events <- event:
+			default:
+				// The sink cannot keep up, events are dropped rather than blocking reconciliation
+				cloudEventsDropped.Inc()
+				e.logger.Warn("dropped cloud event as too many events are pending",
+					"integration", integration, "type", event.Type,
+				)
+			}
+		},
+	)
+}
+
+func (e *changeEmitter) event(
+	integration string, route types.NamespacedName, change integrations.Change,
+) cloudevents.Event {
+	gvk := change.Object.GetObjectKind().GroupVersionKind()
+	if gvk.Empty() {
+		if resolved, err := apiutil.GVKForObject(change.Object, e.scheme); err == nil {
+			gvk = resolved
+		}
+	}
+	resource := ResourceReference{
+		APIVersion: gvk.GroupVersion().String(),
+		Kind:       gvk.Kind,
+		Namespace:  change.Object.GetNamespace(),
+		Name:       change.Object.GetName(),
+	}
+	return cloudevents.Event{
+		ID:      string(uuid.NewUUID()),
+		Source:  e.source,
+		Type:    changeEventTypePrefix + string(change.Operation),
+		Subject: fmt.Sprintf("%s/%s", route.Namespace, route.Name),
+		Time:    time.Now(),
+		Data: ChangeEventData{
+			Integration: integration,
+			Route:       ObjectReference{Namespace: route.Namespace, Name: route.Name},
+			Resource:    resource,
+			Operation:   string(change.Operation),
+			Summary:     change.Summary,
+			Diff:        change.Diff,
+			DryRun:      e.dryRun,
+		},
+	}
+}
@@ -0,0 +1,199 @@
+package controllers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/borchero/switchboard/internal/cloudevents"
+	configv1 "github.com/borchero/switchboard/internal/config/v1"
+	"github.com/borchero/switchboard/internal/integrations"
+	"github.com/borchero/switchboard/internal/k8tests"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	traefik "github.com/traefik/traefik/v3/pkg/provider/kubernetes/crd/traefikio/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+func TestChangeEmitterFromConfig(t *testing.T) {
+	scheme := k8tests.NewScheme()
+
+	emitter, err := changeEmitterFromConfig(nil, false, scheme, slog.Default())
+	require.Nil(t, err)
+	assert.Nil(t, emitter)
+
+	_, err = changeEmitterFromConfig(
+		&configv1.CloudEventsConfig{}, false, scheme, slog.Default(),
+	)
+	assert.NotNil(t, err)
+
+	emitter, err = changeEmitterFromConfig(
+		&configv1.CloudEventsConfig{SinkURL: "http://sink.example.com"}, false, scheme,
+		slog.Default(),
+	)
+	require.Nil(t, err)
+	assert.Equal(t, "switchboard", emitter.source)
+}
+
+func TestChangeEmitterEvent(t *testing.T) {
+	var headers http.Header
+	var data ChangeEventData
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &data)
+	}))
+	defer server.Close()
+
+	emitter, err := changeEmitterFromConfig(
+		&configv1.CloudEventsConfig{SinkURL: server.URL, Source: "/test"}, false,
+		k8tests.NewScheme(), slog.Default(),
+	)
+	require.Nil(t, err)
+	route := types.NamespacedName{Name: "my-route", Namespace: "my-namespace"}
+
+	// Without an emitter, the context should remain unchanged
+	ctx := context.Background()
+	var nilEmitter *changeEmitter
+	assert.Equal(t, ctx, nilEmitter.withChangeHandler(ctx, "coredns", route))
+
+	// Changes should be sent to the sink
+	event := emitter.event("coredns", route, integrations.Change{
+		Operation: controllerutil.OperationResultUpdated,
+		Object: &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "coredns-custom", Namespace: "kube-system"},
+		},
+		Summary: "changed data",
+	})
+	err = emitter.client.Send(ctx, event)
+	require.Nil(t, err)
+	assert.Equal(t, "com.borchero.switchboard.resource.updated", headers.Get("ce-type"))
+	assert.Equal(t, "/test", headers.Get("ce-source"))
+	assert.Equal(t, "my-namespace/my-route", headers.Get("ce-subject"))
+	assert.Equal(t, ChangeEventData{
+		Integration: "coredns",
+		Route:       ObjectReference{Namespace: "my-namespace", Name: "my-route"},
+		Resource: ResourceReference{
+			APIVersion: "v1", Kind: "ConfigMap", Namespace: "kube-system", Name: "coredns-custom",
+		},
+		Operation: "updated",
+		Summary:   "changed data",
+	}, data)
+}
+
+func TestChangeEmitterDropsEvents(t *testing.T) {
+	scheme := k8tests.NewScheme()
+	emitter, err := changeEmitterFromConfig(
+		&configv1.CloudEventsConfig{SinkURL: "http://sink.example.com"}, false, scheme,
+		slog.Default(),
+	)
+	require.Nil(t, err)
+	emitter.events = make(chan cloudevents.Event, 1)
+
+	// Reflect a TLS secret into three namespaces without a running emitter
+	route := &traefik.IngressRoute{ObjectMeta: metav1.ObjectMeta{
+		Name:      "my-route",
+		Namespace: "default",
+		Annotations: map[string]string{
+			"switchboard.borchero.com/reflect-tls-to": "tenant-a,tenant-b,tenant-c",
+		},
+	}}
+	source := &v1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name:        "my-route-tls",
+		Namespace:   "default",
+		Annotations: map[string]string{"cert-manager.io/certificate-name": "my-route-tls"},
+	}}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(source).Build()
+	reflector := integrations.NewReflector(c, []string{"tenant-*"})
+	info := integrations.IngressInfo{Hosts: []string{"example.com"}, TLSSecretName: &source.Name}
+	ctx := emitter.withChangeHandler(
+		context.Background(), reflector.Name(), client.ObjectKeyFromObject(route),
+	)
+	dropped := testutil.ToFloat64(cloudEventsDropped)
+	err = reflector.UpdateResource(ctx, route, info)
+	require.Nil(t, err)
+
+	// Only the first event fits into the buffer, the others are dropped
+	assert.Len(t, emitter.events, 1)
+	assert.Equal(t, dropped+2, testutil.ToFloat64(cloudEventsDropped))
+}
+
+func TestChangeEmitterRedactsSecrets(t *testing.T) {
+	var mutex sync.Mutex
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mutex.Lock()
+		defer mutex.Unlock()
+		bodies = append(bodies, string(body))
+	}))
+	defer server.Close()
+
+	scheme := k8tests.NewScheme()
+	emitter, err := changeEmitterFromConfig(
+		&configv1.CloudEventsConfig{SinkURL: server.URL}, false, scheme, slog.Default(),
+	)
+	require.Nil(t, err)
+	emitterCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = emitter.Start(emitterCtx) }()
+
+	// Reflect a TLS secret into another namespace
+	route := &traefik.IngressRoute{ObjectMeta: metav1.ObjectMeta{
+		Name:        "my-route",
+		Namespace:   "default",
+		Annotations: map[string]string{"switchboard.borchero.com/reflect-tls-to": "tenant"},
+	}}
+	source := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "my-route-tls",
+			Namespace:   "default",
+			Annotations: map[string]string{"cert-manager.io/certificate-name": "my-route-tls"},
+		},
+		Data: map[string][]byte{"tls.key": []byte("private-key")},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(source).Build()
+	reflector := integrations.NewReflector(c, []string{"tenant"})
+	info := integrations.IngressInfo{Hosts: []string{"example.com"}, TLSSecretName: &source.Name}
+	ctx := emitter.withChangeHandler(
+		context.Background(), reflector.Name(), client.ObjectKeyFromObject(route),
+	)
+	err = reflector.UpdateResource(ctx, route, info)
+	require.Nil(t, err)
+
+	// Renew the certificate
+	source.Data = map[string][]byte{"tls.key": []byte("renewed-key")}
+	err = c.Update(ctx, source)
+	require.Nil(t, err)
+	err = reflector.UpdateResource(ctx, route, info)
+	require.Nil(t, err)
+
+	// Neither event must contain any key material
+	require.Eventually(t, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return len(bodies) == 2
+	}, time.Second, 10*time.Millisecond)
+	mutex.Lock()
+	defer mutex.Unlock()
+	for _, body := range bodies {
+		assert.Contains(t, body, "tls.key")
+		for _, key := range []string{"private-key", "renewed-key"} {
+			assert.NotContains(t, body, key)
+			assert.NotContains(t, body, base64.StdEncoding.EncodeToString([]byte(key)))
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/borchero/switchboard/internal/integrations"
+	traefik "github.com/traefik/traefik/v3/pkg/provider/kubernetes/crd/traefikio/v1alpha1"
+)
+
+const (
+	defaultDependencyTimeout = 10 * time.Minute
+
+	eventReasonDependencyTimeout = "DependencyTimeout"
+)
+
+// sortIntegrations orders the given integrations such that every integration comes after the
+// integrations it depends on. The original order is preserved wherever possible. An error is
+// returned if dependencies are unknown or cyclic.
+func sortIntegrations(
+	itgs []integrations.Integration,
+) ([]integrations.Integration, error) {
+	names := make([]string, 0, len(itgs))
+	for _, itg := range itgs {
+		names = append(names, itg.Name())
+	}
+	for _, itg := range itgs {
+		for _, dependency := range dependenciesOf(itg) {
+			if !slices.Contains(names, dependency) {
+				return nil, fmt.Errorf(
+					"integration %q depends on unknown integration %q", itg.Name(), dependency,
+				)
+			}
+		}
+	}
+
+	result := make([]integrations.Integration, 0, len(itgs))
+	done := make(map[string]bool, len(itgs))
+	for len(result) < len(itgs) {
+		progress := false
+		for _, itg := range itgs {
+			if done[itg.Name()] {
+				continue
+			}
+			if !slices.ContainsFunc(dependenciesOf(itg), func(dependency string) bool {
+				return !done[dependency]
+			}) {
+				result = append(result, itg)
+				done[itg.Name()] = true
+				progress = true
+				break
+			}
+		}
+		if !progress {
+			var remaining []string
+			for _, name := range names {
+				if !done[name] {
+					remaining = append(remaining, name)
+				}
+			}
+			return nil, fmt.Errorf(
+				"cyclic dependencies between integrations %s", strings.Join(remaining, ", "),
+			)
+		}
+	}
+	return result, nil
+}
+
+func dependenciesOf(itg integrations.Integration) []string {
+	if dependent, ok := itg.(integrations.Dependent); ok {
+		return dependent.Dependencies()
+	}
+	return nil
+}
+
+//-------------------------------------------------------------------------------------------------
+// READINESS
+//-------------------------------------------------------------------------------------------------
+
+// dependencyWaits tracks since when integrations have been waiting for their dependencies.
+type dependencyWaits struct {
+	mutex sync.Mutex
+	since map[string]time.Time
+	now   func() time.Time
+}
+
+func newDependencyWaits() *dependencyWaits {
+	return &dependencyWaits{since: make(map[string]time.Time), now: time.Now}
+}
+
+// waited returns for how long the integration has been waiting for the given route.
+func (w *dependencyWaits) waited(route *traefik.IngressRoute, integration string) time.Duration {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	key := w.key(route, integration)
+	since, ok := w.since[key]
+	if !ok {
+		since = w.now()
+		w.since[key] = since
+	}
+	return w.now().Sub(since)
+}
+
+// done stops tracking the wait of the integration for the given route.
+func (w *dependencyWaits) done(route *traefik.IngressRoute, integration string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	delete(w.since, w.key(route, integration))
+}
+
+// forget stops tracking all waits for the route with the given namespace and name.
+func (w *dependencyWaits) forget(namespace, name string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	prefix := fmt.Sprintf("%s/%s/", namespace, name)
+	for key := range w.since {
+		if strings.HasPrefix(key, prefix) {
+			delete(w.since, key)
+		}
+	}
+}
+
+func (*dependencyWaits) key(route *traefik.IngressRoute, integration string) string {
+	return fmt.Sprintf("%s/%s/%s", route.Namespace, route.Name, integration)
+}
+
+// awaitDependencies checks whether all dependencies of the integration are ready for the given
+// ingress route. If they are not, the returned duration indicates how long the integration should
+// still wait for them along with a message describing the pending dependencies. Once the
+// dependency timeout has passed, the integration is run regardless until the dependencies become
+// ready. In dry-run mode, dependencies never become ready as their resources are not written, such
+// that integrations never wait.
+func (r *IngressRouteReconciler) awaitDependencies(
+	ctx context.Context,
+	itg integrations.Integration,
+	ingressRoute *traefik.IngressRoute,
+	info integrations.IngressInfo,
+	logger *slog.Logger,
+) (time.Duration, string) {
+	if r.dryRun {
+		return 0, ""
+	}
+	var pending []string
+	for _, name := range dependenciesOf(itg) {
+		if !r.selector.MatchesIntegration(ingressRoute.Annotations, name) {
+			// Ignored dependencies are never waited for
+			continue
+		}
+		index := slices.IndexFunc(r.integrations, func(dependency integrations.Integration) bool {
+			return dependency.Name() == name
+		})
+		checker, ok := r.integrations[index].(integrations.ReadinessChecker)
+		if !ok {
+			continue
+		}
+		ready, err := checker.IsReady(ctx, ingressRoute, info)
+		if err != nil {
+			logger.Error("failed to check readiness",
+				"integration", itg.Name(), "dependency", name, "error", err,
+			)
+		}
+		if !ready {
+			pending = append(pending, name)
+		}
+	}
+	if len(pending) == 0 {
+		r.waits.done(ingressRoute, itg.Name())
+		return 0, ""
+	}
+
+	message := fmt.Sprintf("Waiting for integrations %s", strings.Join(pending, ", "))
+	waited := r.waits.waited(ingressRoute, itg.Name())
+	if waited < r.dependencyTimeout {
+		return r.dependencyTimeout - waited, message
+	}
+
+	// Eventually, we stop waiting for the dependencies
+	logger.Info("dependencies did not become ready in time",
+		"integration", itg.Name(), "dependencies", pending,
+	)
+	r.recordWarning(ingressRoute, eventReasonDependencyTimeout,
+		"Integrations %s did not become ready in time, running %s anyway",
+		strings.Join(pending, ", "), itg.Name(),
+	)
+	return 0, ""
+}
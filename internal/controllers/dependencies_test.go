@@ -0,0 +1,189 @@
+package controllers
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/borchero/switchboard/internal/integrations"
+	"github.com/borchero/switchboard/internal/k8tests"
+	"github.com/borchero/switchboard/internal/switchboard"
+	certmanager "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	traefik "github.com/traefik/traefik/v3/pkg/provider/kubernetes/crd/traefikio/v1alpha1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestSortIntegrations(t *testing.T) {
+	certManager := integrations.NewCertManager(nil, certmanager.Certificate{},
+		integrations.WithCertManagerDependencies("external-dns"),
+	)
+	externalDNS := integrations.NewExternalDNS(nil, switchboard.NewStaticTarget("127.0.0.1"), nil)
+	redirect := integrations.NewRedirect(nil, "", traefik.MiddlewareRef{Name: "redirect"})
+
+	// Dependencies should be moved before their dependents
+	sorted, err := sortIntegrations([]integrations.Integration{certManager, redirect, externalDNS})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"redirect", "external-dns", "cert-manager"}, namesOf(sorted))
+
+	// Unknown dependencies should be rejected
+	_, err = sortIntegrations([]integrations.Integration{certManager, redirect})
+	require.NotNil(t, err)
+
+	// Cyclic dependencies should be rejected
+	selfDependent := integrations.NewCertManager(nil, certmanager.Certificate{},
+		integrations.WithCertManagerDependencies("cert-manager"),
+	)
+	_, err = sortIntegrations([]integrations.Integration{selfDependent})
+	require.NotNil(t, err)
+}
+
+func TestDependencyWaits(t *testing.T) {
+	now := time.Now()
+	waits := newDependencyWaits()
+	waits.now = func() time.Time { return now }
+	route := &traefik.IngressRoute{ObjectMeta: metav1.ObjectMeta{Name: "my-route", Namespace: "ns"}}
+
+	// Waits should be measured from the first check
+	assert.Equal(t, time.Duration(0), waits.waited(route, "cert-manager"))
+	now = now.Add(time.Minute)
+	assert.Equal(t, time.Minute, waits.waited(route, "cert-manager"))
+
+	// Finished waits should be reset
+	waits.done(route, "cert-manager")
+	assert.Equal(t, time.Duration(0), waits.waited(route, "cert-manager"))
+
+	// Forgotten routes should be reset
+	now = now.Add(time.Minute)
+	waits.forget("ns", "my-route")
+	assert.Equal(t, time.Duration(0), waits.waited(route, "cert-manager"))
+}
+
+func TestAwaitDependencies(t *testing.T) {
+	// Setup: the cache has not seen the DNS endpoint that was just created
+	ctx := context.Background()
+	c := fake.NewClientBuilder().
+		WithScheme(k8tests.NewScheme()).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(
+				_ context.Context,
+				_ client.WithWatch,
+				key client.ObjectKey,
+				_ client.Object,
+				_ ...client.GetOption,
+			) error {
+				return apierrs.NewNotFound(schema.GroupResource{}, key.Name)
+			},
+		}).
+		Build()
+	externalDNS := integrations.NewExternalDNS(c, switchboard.NewStaticTarget("127.0.0.1"), nil)
+	certManager := integrations.NewCertManager(c, certmanager.Certificate{},
+		integrations.WithCertManagerDependencies("external-dns"),
+	)
+	now := time.Now()
+	r := IngressRouteReconciler{
+		Client:            c,
+		integrations:      []integrations.Integration{externalDNS, certManager},
+		dependencyTimeout: time.Minute,
+		waits:             newDependencyWaits(),
+	}
+	r.waits.now = func() time.Time { return now }
+	route := &traefik.IngressRoute{ObjectMeta: metav1.ObjectMeta{Name: "my-route", Namespace: "ns"}}
+	info := integrations.IngressInfo{Hosts: []string{"example.com"}}
+
+	// Integrations without dependencies should never wait
+	wait, _ := r.awaitDependencies(ctx, externalDNS, route, info, slog.Default())
+	assert.Equal(t, time.Duration(0), wait)
+
+	// Certificates should wait for the DNS endpoint that is not in the cache yet
+	wait, message := r.awaitDependencies(ctx, certManager, route, info, slog.Default())
+	assert.Equal(t, time.Minute, wait)
+	assert.Equal(t, "Waiting for integrations external-dns", message)
+
+	// Routes without hosts have no DNS endpoint to wait for
+	noHosts := integrations.IngressInfo{}
+	wait, _ = r.awaitDependencies(ctx, certManager, route, noHosts, slog.Default())
+	assert.Equal(t, time.Duration(0), wait)
+
+	// Ignored dependencies should not be waited for
+	route.Annotations = map[string]string{"switchboard.borchero.com/ignore": "external-dns"}
+	wait, _ = r.awaitDependencies(ctx, certManager, route, info, slog.Default())
+	assert.Equal(t, time.Duration(0), wait)
+
+	// Integrations should run regardless once the timeout passed
+	route.Annotations = nil
+	wait, _ = r.awaitDependencies(ctx, certManager, route, info, slog.Default())
+	assert.Equal(t, time.Minute, wait)
+	now = now.Add(40 * time.Second)
+	wait, _ = r.awaitDependencies(ctx, certManager, route, info, slog.Default())
+	assert.Equal(t, 20*time.Second, wait)
+	now = now.Add(time.Minute)
+	wait, _ = r.awaitDependencies(ctx, certManager, route, info, slog.Default())
+	assert.Equal(t, time.Duration(0), wait)
+
+	// In dry-run mode, integrations should never wait as dependencies are never written
+	r.dryRun = true
+	r.waits = newDependencyWaits()
+	wait, _ = r.awaitDependencies(ctx, certManager, route, info, slog.Default())
+	assert.Equal(t, time.Duration(0), wait)
+}
+
+func TestForgetRoutes(t *testing.T) {
+	ctx := context.Background()
+	route := &traefik.IngressRoute{ObjectMeta: metav1.ObjectMeta{
+		Name:        "my-route",
+		Namespace:   "ns",
+		Annotations: map[string]string{"kubernetes.io/ingress.class": "other"},
+	}}
+	c := fake.NewClientBuilder().WithScheme(k8tests.NewScheme()).WithObjects(route).Build()
+	itg := &forgetIntegration{metricsIntegration: metricsIntegration{name: "forget"}}
+	ingressClass := "traefik"
+	r := IngressRouteReconciler{
+		Client:       c,
+		logger:       slog.New(slog.DiscardHandler),
+		selector:     switchboard.NewSelector(&ingressClass),
+		integrations: []integrations.Integration{itg},
+		waits:        newDependencyWaits(),
+	}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(route)}
+
+	// Integrations should forget routes which are not selected anymore...
+	_, err := r.Reconcile(ctx, req)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"ns/my-route"}, itg.forgotten)
+
+	// ...as well as deleted routes
+	require.Nil(t, c.Delete(ctx, route))
+	_, err = r.Reconcile(ctx, req)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"ns/my-route", "ns/my-route"}, itg.forgotten)
+}
+
+//-------------------------------------------------------------------------------------------------
+// UTILS
+//-------------------------------------------------------------------------------------------------
+
+type forgetIntegration struct {
+	metricsIntegration
+	forgotten []string
+}
+
+func (i *forgetIntegration) Forget(namespace, name string) {
+	i.forgotten = append(i.forgotten, namespace+"/"+name)
+}
+
+func namesOf(itgs []integrations.Integration) []string {
+	names := make([]string, 0, len(itgs))
+	for _, itg := range itgs {
+		names = append(names, itg.Name())
+	}
+	return names
+}
@@ -0,0 +1,38 @@
+package controllers
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/borchero/switchboard/internal/integrations"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// withMetricsOrDryRun returns a context which causes the integration with the given name to count
+// all operations on the resources that it manages. In dry-run mode, the context additionally
+// prevents updates of external systems and causes all changes to be logged along with their diff.
+// Diffs never contain the values of secrets, only the names of their keys.
+func (r *IngressRouteReconciler) withMetricsOrDryRun(
+	ctx context.Context, integration string, logger *slog.Logger,
+) context.Context {
+	if !r.dryRun {
+		return withMetrics(ctx, integration)
+	}
+	ctx = integrations.WithDryRun(ctx)
+	return integrations.WithChangeHandler(
+		ctx, func(_ context.Context, change integrations.Change) {
+			dryRunOperations.WithLabelValues(integration, string(change.Operation)).Inc()
+			if change.Operation == controllerutil.OperationResultNone {
+				return
+			}
+			logger.Info("dry-run: skipping change of resource",
+				"integration", integration,
+				"operation", change.Operation,
+				"kind", r.kindOf(change.Object),
+				"namespace", change.Object.GetNamespace(),
+				"resource", change.Object.GetName(),
+				"diff", change.Diff,
+			)
+		},
+	)
+}
@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"log/slog"
+	"testing"
+
+	"github.com/borchero/switchboard/internal/integrations"
+	"github.com/borchero/switchboard/internal/k8s"
+	"github.com/borchero/switchboard/internal/k8tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	traefik "github.com/traefik/traefik/v3/pkg/provider/kubernetes/crd/traefikio/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestDryRunRedactsSecrets(t *testing.T) {
+	// Setup
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+	source := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "my-route-tls",
+			Namespace:   "default",
+			Annotations: map[string]string{"cert-manager.io/certificate-name": "my-route-tls"},
+		},
+		Data: map[string][]byte{"tls.key": []byte("private-key")},
+	}
+	c := k8s.NewDryRunClient(
+		fake.NewClientBuilder().WithScheme(k8tests.NewScheme()).WithObjects(source).Build(),
+	)
+	r := IngressRouteReconciler{Client: c, logger: logger, dryRun: true}
+	route := &traefik.IngressRoute{ObjectMeta: metav1.ObjectMeta{
+		Name:        "my-route",
+		Namespace:   "default",
+		Annotations: map[string]string{"switchboard.borchero.com/reflect-tls-to": "tenant"},
+	}}
+	reflector := integrations.NewReflector(c, []string{"tenant"})
+	info := integrations.IngressInfo{Hosts: []string{"example.com"}, TLSSecretName: &source.Name}
+
+	// The copy that would be created must be logged without any key material
+	ctx := r.withMetricsOrDryRun(context.Background(), reflector.Name(), logger)
+	err := reflector.UpdateResource(ctx, route, info)
+	require.Nil(t, err)
+	assert.Contains(t, logs.String(), "dry-run: skipping change of resource")
+	assert.Contains(t, logs.String(), "tls.key")
+	assert.NotContains(t, logs.String(), "private-key")
+	assert.NotContains(t, logs.String(), base64.StdEncoding.EncodeToString([]byte("private-key")))
+}
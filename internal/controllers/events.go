@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/borchero/switchboard/internal/integrations"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	eventActionReconcile = "Reconcile"
+
+	eventReasonInvalidHosts       = "InvalidHosts"
+	eventReasonIntegrationFailed  = "IntegrationFailed"
+	eventReasonUnknownIntegration = "UnknownIntegration"
+)
+
+// withEventRecorder returns a context which causes the integration with the given name to record
+// an event on the ingress route for all changes that it makes.
+func (r *IngressRouteReconciler) withEventRecorder(
+	ctx context.Context, integration string, route client.Object,
+) context.Context {
+	if r.recorder == nil {
+		return ctx
+	}
+	return integrations.WithChangeHandler(
+		ctx, func(_ context.Context, change integrations.Change) {
+			if change.Operation == controllerutil.OperationResultNone {
+				return
+			}
+			r.recorder.Eventf(
+				route, change.Object, v1.EventTypeNormal, eventReason(change), eventActionReconcile,
+				"%s %s %s/%s via %s integration", eventReason(change), r.kindOf(change.Object),
+				change.Object.GetNamespace(), change.Object.GetName(), integration,
+			)
+		},
+	)
+}
+
+// recordWarning records a warning event on the ingress route.
+func (r *IngressRouteReconciler) recordWarning(
+	route runtime.Object, reason string, note string, args ...any,
+) {
+	if r.recorder == nil {
+		return
+	}
+	r.recorder.Eventf(route, nil, v1.EventTypeWarning, reason, eventActionReconcile, note, args...)
+}
+
+// recordUnknownIntegrations records a warning event on the ingress route if its ignore annotation
+// references integrations that are not enabled (which is most likely a typo).
+func (r *IngressRouteReconciler) recordUnknownIntegrations(route client.Object) {
+	var unknown []string
+	for _, name := range r.selector.IgnoredIntegrations(route.GetAnnotations()) {
+		if !slices.ContainsFunc(r.integrations, func(itg integrations.Integration) bool {
+			return itg.Name() == name
+		}) {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		r.recordWarning(route, eventReasonUnknownIntegration,
+			"Ignore annotation references integrations which are not enabled: %s",
+			strings.Join(unknown, ", "),
+		)
+	}
+}
+
+//-------------------------------------------------------------------------------------------------
+// UTILS
+//-------------------------------------------------------------------------------------------------
+
+func (r *IngressRouteReconciler) kindOf(obj runtime.Object) string {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	if gvk.Empty() {
+		if resolved, err := apiutil.GVKForObject(obj, r.Scheme()); err == nil {
+			gvk = resolved
+		}
+	}
+	return gvk.Kind
+}
+
+// eventReason returns the capitalized operation of the change, e.g. `Created`.
+func eventReason(change integrations.Change) string {
+	operation := string(change.Operation)
+	if operation == "" {
+		return ""
+	}
+	return strings.ToUpper(operation[:1]) + operation[1:]
+}
@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/borchero/switchboard/internal/integrations"
+	"github.com/borchero/switchboard/internal/switchboard"
+	"github.com/stretchr/testify/assert"
+	traefik "github.com/traefik/traefik/v3/pkg/provider/kubernetes/crd/traefikio/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+func TestRecordUnknownIntegrations(t *testing.T) {
+	recorder := events.NewFakeRecorder(10)
+	reconciler := IngressRouteReconciler{
+		selector:     switchboard.NewSelector(nil),
+		integrations: []integrations.Integration{integrations.NewReflector(nil, nil)},
+		recorder:     recorder,
+	}
+	route := &traefik.IngressRoute{ObjectMeta: metav1.ObjectMeta{Name: "my-route"}}
+
+	// Known integrations should not be reported
+	route.Annotations = map[string]string{"switchboard.borchero.com/ignore": "reflector"}
+	reconciler.recordUnknownIntegrations(route)
+	assert.Len(t, recorder.Events, 0)
+
+	// Unknown integrations should be reported
+	route.Annotations = map[string]string{"switchboard.borchero.com/ignore": "reflector,certmanager"}
+	reconciler.recordUnknownIntegrations(route)
+	assert.Len(t, recorder.Events, 1)
+	assert.Equal(t,
+		"Warning UnknownIntegration Ignore annotation references integrations which are not "+
+			"enabled: certmanager",
+		<-recorder.Events,
+	)
+
+	// Without a recorder, nothing should happen
+	reconciler.recorder = nil
+	reconciler.recordUnknownIntegrations(route)
+}
+
+func TestEventReason(t *testing.T) {
+	assert.Equal(t, "Created", eventReason(integrations.Change{
+		Operation: controllerutil.OperationResultCreated,
+	}))
+	assert.Equal(t, "Deleted", eventReason(integrations.Change{
+		Operation: integrations.OperationResultDeleted,
+	}))
+}
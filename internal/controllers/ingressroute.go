@@ -2,15 +2,27 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"sync"
+	"time"
 
+	"github.com/borchero/switchboard/internal/api/v1alpha1"
 	configv1 "github.com/borchero/switchboard/internal/config/v1"
 	"github.com/borchero/switchboard/internal/ext"
 	"github.com/borchero/switchboard/internal/integrations"
+	"github.com/borchero/switchboard/internal/k8s"
 	"github.com/borchero/switchboard/internal/switchboard"
+	"github.com/borchero/switchboard/internal/tracing"
 	traefik "github.com/traefik/traefik/v3/pkg/provider/kubernetes/crd/traefikio/v1alpha1"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/errgroup"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -21,21 +33,50 @@ type IngressRouteReconciler struct {
 	logger       *slog.Logger
 	selector     switchboard.Selector
 	integrations []integrations.Integration
+	changes      *changeEmitter
+	recorder     events.EventRecorder
+	routeStatus  bool
+	concurrency  int
+	dryRun       bool
+
+	dependencyTimeout time.Duration
+	waits             *dependencyWaits
 }
 
 // NewIngressRouteReconciler creates a new IngressRouteReconciler.
 func NewIngressRouteReconciler(
 	client client.Client, logger *slog.Logger, config configv1.Config,
 ) (IngressRouteReconciler, error) {
+	if config.DryRun {
+		// All writes are dropped, including the ones of the route status
+		client = k8s.NewDryRunClient(client)
+	}
 	integrations, err := integrationsFromConfig(config, client)
 	if err != nil {
 		return IngressRouteReconciler{}, fmt.Errorf("failed to initialize integrations: %s", err)
 	}
+	dependencyTimeout := defaultDependencyTimeout
+	if config.DependencyTimeout != nil {
+		dependencyTimeout = config.DependencyTimeout.Duration
+	}
+	changes, err := changeEmitterFromConfig(
+		config.CloudEvents, config.DryRun, client.Scheme(), logger,
+	)
+	if err != nil {
+		return IngressRouteReconciler{}, fmt.Errorf("failed to initialize cloud events: %s", err)
+	}
 	return IngressRouteReconciler{
 		Client:       client,
 		logger:       logger,
 		selector:     switchboard.NewSelector(config.Selector.IngressClass),
 		integrations: integrations,
+		changes:      changes,
+		routeStatus:  config.RouteStatus.Enabled,
+		concurrency:  config.IntegrationConcurrency,
+		dryRun:       config.DryRun,
+
+		dependencyTimeout: dependencyTimeout,
+		waits:             newDependencyWaits(),
 	}, nil
 }
 
@@ -45,6 +86,11 @@ func (r *IngressRouteReconciler) Reconcile(
 	ctx context.Context, req ctrl.Request,
 ) (ctrl.Result, error) {
 	logger := r.logger.With("name", req.String())
+	ctx, span := tracer.Start(ctx, "Reconcile", trace.WithAttributes(
+		attribute.String(attributeRouteNamespace, req.Namespace),
+		attribute.String(attributeRouteName, req.Name),
+	))
+	defer span.End()
 
 	// First, we retrieve the full resource
 	var ingressRoute traefik.IngressRoute
@@ -52,56 +98,306 @@ func (r *IngressRouteReconciler) Reconcile(
 	if err := r.Get(ctx, req.NamespacedName, &ingressRoute); err != nil {
 		if !apierrs.IsNotFound(err) {
 			logger.Error("unable to query for ingress route", "error", err)
+			tracing.RecordError(span, err)
+			return ctrl.Result{}, err
 		}
-		return ctrl.Result{}, client.IgnoreNotFound(err)
+		span.SetAttributes(attribute.String(attributeResult, resultDeleted))
+		err := r.cleanupUnownedResources(ctx, req, logger)
+		tracing.RecordError(span, err)
+		return ctrl.Result{}, err
 	}
 
 	// Then, we check if the resource should be processed
 	if !r.selector.Matches(ingressRoute.Annotations) {
 		logger.Debug("ignoring ingress route")
+		routesTotal.WithLabelValues(routeResultIgnored).Inc()
+		span.SetAttributes(attribute.String(attributeResult, routeResultIgnored))
+		// State and a route status kept while the ingress route was selected are outdated
+		r.forget(req.Namespace, req.Name)
+		if err := r.deleteRouteStatus(ctx, &ingressRoute); err != nil {
+			logger.Error("failed to delete route status", "error", err)
+			tracing.RecordError(span, err)
+			return ctrl.Result{}, err
+		}
 		return ctrl.Result{}, nil
 	}
+	routesTotal.WithLabelValues(routeResultSelected).Inc()
 	logger.Debug("reconciling ingress route")
 
 	// Now, we have to ensure that all the dependent resources exist by calling all integrations.
 	// For this, we first have to extract information about the ingress.
-	collection, err := switchboard.NewHostCollection().
-		WithTLSHostsIfAvailable(ingressRoute.Spec.TLS).
-		WithRouteHostsIfRequired(ingressRoute.Spec.Routes)
+	_, hostsSpan := tracer.Start(ctx, "ParseHosts")
+	info, err := ingressInfo(&ingressRoute)
+	tracing.RecordError(hostsSpan, err)
+	hostsSpan.End()
 	if err != nil {
 		logger.Error("failed to parse hosts from ingress route", "error", err)
+		r.recordWarning(&ingressRoute, eventReasonInvalidHosts,
+			"Failed to parse hosts from ingress route: %s", err,
+		)
+		// None of the integrations can run without hosts
+		status := r.newRouteStatus(&ingressRoute, nil)
+		for _, itg := range r.integrations {
+			if r.selector.MatchesIntegration(ingressRoute.Annotations, itg.Name()) {
+				status.failed(itg.Name(), fmt.Errorf("failed to parse hosts: %w", err))
+			} else {
+				status.ignored(itg.Name())
+			}
+		}
+		if statusErr := r.updateRouteStatus(ctx, &ingressRoute, status); statusErr != nil {
+			logger.Error("failed to update route status", "error", statusErr)
+			err = errors.Join(err, statusErr)
+		}
+		tracing.RecordError(span, err)
 		return ctrl.Result{}, err
 	}
-	info := integrations.IngressInfo{
-		Hosts: collection.Hosts(),
-		TLSSecretName: ext.AndThen(ingressRoute.Spec.TLS, func(tls traefik.TLS) string {
-			return tls.SecretName
-		}),
-	}
+	routeHosts.Observe(float64(len(info.Hosts)))
+	span.SetAttributes(attribute.StringSlice(attributeHosts, info.Hosts))
 
-	// Then, we can run the integrations
-	for _, itg := range r.integrations {
+	// Then, we can run all integrations, collecting errors instead of stopping at the first one
+	status := r.newRouteStatus(&ingressRoute, info.Hosts)
+	r.recordUnknownIntegrations(&ingressRoute)
+	var requeue requeueAfter
+	errs := r.forEachIntegration(ctx, func(ctx context.Context, itg integrations.Integration) error {
 		if !r.selector.MatchesIntegration(ingressRoute.Annotations, itg.Name()) {
 			// If integration is ignored, skip it
 			logger.Debug("ignoring integration", "integration", itg.Name())
-			continue
+			status.ignored(itg.Name())
+			return nil
 		}
-		if err := itg.UpdateResource(ctx, &ingressRoute, info); err != nil {
-			logger.Error("failed to upsert resource",
-				"integration", itg.Name(), "error", err,
-			)
-			return ctrl.Result{}, err
+		wait, message := r.awaitDependencies(ctx, itg, &ingressRoute, info, logger)
+		if wait > 0 {
+			// If dependencies are not ready yet, the integration is run later
+			logger.Debug("waiting for dependencies", "integration", itg.Name())
+			status.waiting(itg.Name(), message)
+			requeue.update(wait)
+			_, itgSpan := tracer.Start(ctx, "Integration "+itg.Name(), trace.WithAttributes(
+				attribute.String(attributeIntegration, itg.Name()),
+				attribute.String(attributeResult, resultWaiting),
+			))
+			itgSpan.End()
+			return nil
 		}
-		logger.Debug("successfully upserted resource", "integration", itg.Name())
+		return r.runIntegration(ctx, itg, &ingressRoute, info, status, logger)
+	})
+
+	// Eventually, we report the outcome in the route status
+	if err := r.updateRouteStatus(ctx, &ingressRoute, status); err != nil {
+		logger.Error("failed to update route status", "error", err)
+		errs = append(errs, err)
+	}
+	if err := errors.Join(errs...); err != nil {
+		span.SetAttributes(attribute.String(attributeResult, resultError))
+		tracing.RecordError(span, err)
+		return ctrl.Result{}, err
+	}
+	if wait := requeue.get(); wait > 0 {
+		logger.Info("ingress route is waiting for dependencies")
+		span.SetAttributes(attribute.String(attributeResult, resultWaiting))
+		return ctrl.Result{RequeueAfter: wait}, nil
 	}
 
 	logger.Info("ingress route is up to date")
+	span.SetAttributes(attribute.String(attributeResult, resultReady))
 	return ctrl.Result{}, nil
 }
 
+// runIntegration runs a single integration for the given ingress route and records the outcome.
+func (r *IngressRouteReconciler) runIntegration(
+	ctx context.Context,
+	itg integrations.Integration,
+	ingressRoute *traefik.IngressRoute,
+	info integrations.IngressInfo,
+	status *routeStatusBuilder,
+	logger *slog.Logger,
+) error {
+	ctx, span := tracer.Start(ctx, "Integration "+itg.Name(), trace.WithAttributes(
+		attribute.String(attributeIntegration, itg.Name()),
+	))
+	defer span.End()
+
+	route := types.NamespacedName{Name: ingressRoute.Name, Namespace: ingressRoute.Namespace}
+	itgCtx := r.changes.withChangeHandler(ctx, itg.Name(), route)
+	itgCtx = r.withEventRecorder(itgCtx, itg.Name(), ingressRoute)
+	itgCtx = status.withResourceTracking(itgCtx, itg.Name())
+	itgCtx = r.withMetricsOrDryRun(itgCtx, itg.Name(), logger)
+	start := time.Now()
+	err := itg.UpdateResource(itgCtx, ingressRoute, info)
+	integrationDuration.WithLabelValues(itg.Name()).Observe(time.Since(start).Seconds())
+	if err != nil {
+		integrationErrors.WithLabelValues(itg.Name()).Inc()
+		logger.Error("failed to upsert resource",
+			"integration", itg.Name(), "error", err,
+		)
+		r.recordWarning(ingressRoute, eventReasonIntegrationFailed,
+			"Integration %s failed: %s", itg.Name(), err,
+		)
+		status.failed(itg.Name(), err)
+		span.SetAttributes(attribute.String(attributeResult, resultError))
+		tracing.RecordError(span, err)
+		return fmt.Errorf("integration %s failed: %w", itg.Name(), err)
+	}
+	status.ready(itg.Name())
+	span.SetAttributes(attribute.String(attributeResult, resultReady))
+	if resolver, ok := itg.(integrations.TargetResolver); ok {
+		targets, err := resolver.ResolveTargets(ctx)
+		if err != nil {
+			logger.Error("failed to resolve targets", "integration", itg.Name(), "error", err)
+			targetResolutionErrors.WithLabelValues(itg.Name()).Inc()
+		} else {
+			resolvedTargets.WithLabelValues(itg.Name()).Set(float64(len(targets)))
+			span.SetAttributes(attribute.StringSlice(attributeTargets, targets))
+		}
+		status.addTargets(targets)
+	}
+	logger.Debug("successfully upserted resource", "integration", itg.Name())
+	return nil
+}
+
+// forEachIntegration calls the given function for all integrations, running up to the configured
+// number of integrations concurrently. Integrations are only started once the integrations they
+// depend on have finished. All errors are returned in the order of the integrations.
+func (r *IngressRouteReconciler) forEachIntegration(
+	ctx context.Context, fn func(context.Context, integrations.Integration) error,
+) []error {
+	results := make([]error, len(r.integrations))
+	finished := make(map[string]chan struct{}, len(r.integrations))
+	for _, itg := range r.integrations {
+		finished[itg.Name()] = make(chan struct{})
+	}
+
+	// As integrations are sorted by their dependencies, dependencies are always started first
+	group := errgroup.Group{}
+	group.SetLimit(max(r.concurrency, 1))
+	for i, itg := range r.integrations {
+		group.Go(func() error {
+			defer close(finished[itg.Name()])
+			for _, dependency := range dependenciesOf(itg) {
+				<-finished[dependency]
+			}
+			results[i] = fn(ctx, itg)
+			return nil
+		})
+	}
+	_ = group.Wait()
+
+	var errs []error
+	for _, err := range results {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// cleanupUnownedResources removes the resources of a deleted ingress route for all integrations
+// that do not own a Kubernetes resource (and, thus, cannot rely on garbage collection).
+func (r *IngressRouteReconciler) cleanupUnownedResources(
+	ctx context.Context, req ctrl.Request, logger *slog.Logger,
+) error {
+	r.forget(req.Namespace, req.Name)
+	owner := metav1.ObjectMeta{Name: req.Name, Namespace: req.Namespace}
+	errs := r.forEachIntegration(ctx, func(ctx context.Context, itg integrations.Integration) error {
+		if itg.OwnedResource() != nil {
+			return nil
+		}
+		ctx, span := tracer.Start(ctx, "Integration "+itg.Name(), trace.WithAttributes(
+			attribute.String(attributeIntegration, itg.Name()),
+		))
+		defer span.End()
+
+		itgCtx := r.changes.withChangeHandler(ctx, itg.Name(), req.NamespacedName)
+		itgCtx = r.withMetricsOrDryRun(itgCtx, itg.Name(), logger)
+		if err := itg.UpdateResource(itgCtx, &owner, integrations.IngressInfo{}); err != nil {
+			integrationErrors.WithLabelValues(itg.Name()).Inc()
+			tracing.RecordError(span, err)
+			logger.Error("failed to clean up resources",
+				"integration", itg.Name(), "error", err,
+			)
+			return fmt.Errorf("integration %s failed: %w", itg.Name(), err)
+		}
+		return nil
+	})
+	return errors.Join(errs...)
+}
+
+// forget drops all in-memory state that is kept for the ingress route with the given namespace and
+// name, both by the reconciler and by the integrations.
+func (r *IngressRouteReconciler) forget(namespace, name string) {
+	r.waits.forget(namespace, name)
+	for _, itg := range r.integrations {
+		if forgetter, ok := itg.(integrations.Forgetter); ok {
+			forgetter.Forget(namespace, name)
+		}
+	}
+}
+
+// ingressInfo extracts the information that integrations act upon from the ingress route.
+func ingressInfo(ingressRoute *traefik.IngressRoute) (integrations.IngressInfo, error) {
+	collection, err := switchboard.NewHostCollection().
+		WithTLSHostsIfAvailable(ingressRoute.Spec.TLS).
+		WithRouteHostsIfRequired(ingressRoute.Spec.Routes)
+	if err != nil {
+		return integrations.IngressInfo{}, err
+	}
+	return integrations.IngressInfo{
+		Hosts: collection.Hosts(),
+		TLSSecretName: ext.AndThen(ingressRoute.Spec.TLS, func(tls traefik.TLS) string {
+			return tls.SecretName
+		}),
+		TLSOptionName: tlsOptionName(ingressRoute),
+		EntryPoints:   ingressRoute.Spec.EntryPoints,
+	}, nil
+}
+
+// tlsOptionName returns the name of the TLS option that the ingress route references if the TLS
+// option resides in the namespace of the ingress route.
+func tlsOptionName(ingressRoute *traefik.IngressRoute) *string {
+	tls := ingressRoute.Spec.TLS
+	if tls == nil || tls.Options == nil || tls.Options.Name == "" {
+		return nil
+	}
+	if tls.Options.Namespace != "" && tls.Options.Namespace != ingressRoute.Namespace {
+		return nil
+	}
+	return &tls.Options.Name
+}
+
+// requeueAfter collects the earliest time after which an ingress route should be reconciled again.
+type requeueAfter struct {
+	mutex sync.Mutex
+	after time.Duration
+}
+
+func (r *requeueAfter) update(after time.Duration) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.after == 0 || after < r.after {
+		r.after = after
+	}
+}
+
+func (r *requeueAfter) get() time.Duration {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.after
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *IngressRouteReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if !r.dryRun {
+		// Events are writes as well and, thus, not recorded in dry-run mode
+		r.recorder = mgr.GetEventRecorder("switchboard")
+	}
+	if r.changes != nil {
+		if err := mgr.Add(r.changes); err != nil {
+			return fmt.Errorf("failed to add cloud event emitter: %w", err)
+		}
+	}
 	builder := ctrl.NewControllerManagedBy(mgr).For(&traefik.IngressRoute{})
+	if r.routeStatus {
+		builder = builder.Owns(&v1alpha1.RouteStatus{})
+	}
 	builder = builderWithIntegrations(builder, r.integrations, r, r.logger)
 	return builder.Complete(r)
 }
@@ -4,10 +4,15 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/borchero/switchboard/internal/api/v1alpha1"
 	configv1 "github.com/borchero/switchboard/internal/config/v1"
+	"github.com/borchero/switchboard/internal/integrations"
 	"github.com/borchero/switchboard/internal/k8tests"
+	"github.com/borchero/switchboard/internal/switchboard"
 	certmanager "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
 	"github.com/stretchr/testify/assert"
@@ -179,6 +184,41 @@ func TestIngressHeader(t *testing.T) {
 	})
 }
 
+func TestForEachIntegration(t *testing.T) {
+	reconciler := IngressRouteReconciler{
+		integrations: []integrations.Integration{
+			integrations.NewReflector(nil, nil),
+			integrations.NewTLSStore(nil, "", switchboard.TLSStoreModeNone),
+			integrations.NewRedirect(nil, "", traefik.MiddlewareRef{Name: "redirect"}),
+		},
+		concurrency: 2,
+	}
+
+	// All integrations should be run, with errors being collected in order
+	var running, maxRunning atomic.Int32
+	errs := reconciler.forEachIntegration(context.Background(),
+		func(_ context.Context, itg integrations.Integration) error {
+			current := running.Add(1)
+			defer running.Add(-1)
+			for {
+				previous := maxRunning.Load()
+				if current <= previous || maxRunning.CompareAndSwap(previous, current) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			if itg.Name() == "tls-store" {
+				return nil
+			}
+			return fmt.Errorf("%s failed", itg.Name())
+		},
+	)
+	require.Len(t, errs, 2)
+	assert.EqualError(t, errs[0], "reflector failed")
+	assert.EqualError(t, errs[1], "redirect failed")
+	assert.LessOrEqual(t, maxRunning.Load(), int32(2))
+}
+
 //-------------------------------------------------------------------------------------------------
 // TESTING UTILITIES
 //-------------------------------------------------------------------------------------------------
@@ -246,6 +286,17 @@ func runTest(t *testing.T, test testCase) {
 			assert.Equal(t, service.Spec.ClusterIP, ep.Targets[0])
 		}
 	}
+
+	// 3) Route status
+	var routeStatus v1alpha1.RouteStatus
+	err = client.Get(ctx, endpointName, &routeStatus)
+	require.Nil(t, err)
+	assert.ElementsMatch(t, test.DNSNames, routeStatus.Status.Hosts)
+	assert.Equal(t, []string{service.Spec.ClusterIP}, routeStatus.Status.Targets)
+	require.Len(t, routeStatus.Status.Integrations, 2)
+	for _, status := range routeStatus.Status.Integrations {
+		assert.Equal(t, v1alpha1.IntegrationStateReady, status.State)
+	}
 }
 
 //-------------------------------------------------------------------------------------------------
@@ -269,11 +320,14 @@ func runReconciliation(
 
 func createConfig(service *v1.Service) configv1.Config {
 	return configv1.Config{
+		RouteStatus: configv1.RouteStatusConfig{Enabled: true},
 		Integrations: configv1.IntegrationConfigs{
 			ExternalDNS: &configv1.ExternalDNSIntegrationConfig{
-				TargetService: &configv1.ServiceRef{
-					Name:      service.Name,
-					Namespace: service.Namespace,
+				TargetConfig: configv1.TargetConfig{
+					TargetService: &configv1.ServiceRef{
+						Name:      service.Name,
+						Namespace: service.Namespace,
+					},
 				},
 			},
 			CertManager: &configv1.CertManagerIntegrationConfig{
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/borchero/switchboard/internal/integrations"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	routeResultSelected = "selected"
+	routeResultIgnored  = "ignored"
+)
+
+var (
+	routesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "switchboard",
+		Name:      "routes_total",
+		Help:      "Number of reconciled ingress routes by whether they were selected or ignored.",
+	}, []string{"result"})
+	routeHosts = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "switchboard",
+		Name:      "route_hosts",
+		Help:      "Number of hosts per reconciled ingress route.",
+		Buckets:   []float64{0, 1, 2, 4, 8, 16, 32, 64},
+	})
+	integrationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "switchboard",
+		Name:      "integration_reconcile_duration_seconds",
+		Help:      "Time taken by an integration to reconcile an ingress route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"integration"})
+	integrationErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "switchboard",
+		Name:      "integration_errors_total",
+		Help:      "Number of failed reconciliations of an integration.",
+	}, []string{"integration"})
+	resourceOperations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "switchboard",
+		Name:      "resource_operations_total",
+		Help:      "Number of operations on resources managed by an integration by their result.",
+	}, []string{"integration", "operation"})
+	dryRunOperations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "switchboard",
+		Name:      "dry_run_operations_total",
+		Help:      "Number of operations that an integration would perform if dry-run was disabled.",
+	}, []string{"integration", "operation"})
+	targetResolutionErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "switchboard",
+		Name:      "target_resolution_errors_total",
+		Help:      "Number of failures to resolve the targets of an integration.",
+	}, []string{"integration"})
+	resolvedTargets = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "switchboard",
+		Name:      "resolved_targets",
+		Help:      "Number of targets that were most recently resolved by an integration.",
+	}, []string{"integration"})
+	cloudEventsDropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "switchboard",
+		Name:      "cloudevents_dropped_total",
+		Help:      "Number of CloudEvents that were dropped because too many events were pending.",
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		routesTotal,
+		routeHosts,
+		integrationDuration,
+		integrationErrors,
+		resourceOperations,
+		dryRunOperations,
+		targetResolutionErrors,
+		resolvedTargets,
+		cloudEventsDropped,
+	)
+}
+
+// withMetrics returns a context which causes the integration with the given name to count all
+// operations on the resources that it manages.
+func withMetrics(ctx context.Context, integration string) context.Context {
+	return integrations.WithChangeHandler(
+		ctx, func(_ context.Context, change integrations.Change) {
+			resourceOperations.WithLabelValues(integration, string(change.Operation)).Inc()
+		},
+	)
+}
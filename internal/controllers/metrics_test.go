@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/borchero/switchboard/internal/integrations"
+	"github.com/borchero/switchboard/internal/k8s"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	traefik "github.com/traefik/traefik/v3/pkg/provider/kubernetes/crd/traefikio/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestIntegrationMetrics(t *testing.T) {
+	reconciler := IngressRouteReconciler{}
+	route := &traefik.IngressRoute{ObjectMeta: metav1.ObjectMeta{Name: "my-route"}}
+	logger := slog.New(slog.DiscardHandler)
+	ctx := context.Background()
+
+	// Successful runs should report the resolved targets
+	itg := &metricsIntegration{name: "metrics-ok", targets: []string{"127.0.0.1", "127.0.0.2"}}
+	err := reconciler.runIntegration(ctx, itg, route, integrations.IngressInfo{}, nil, logger)
+	require.Nil(t, err)
+	assert.Equal(t, 0.0, testutil.ToFloat64(integrationErrors.WithLabelValues("metrics-ok")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(resolvedTargets.WithLabelValues("metrics-ok")))
+	assert.GreaterOrEqual(t, testutil.CollectAndCount(integrationDuration), 1)
+
+	// Failed runs should be counted
+	itg = &metricsIntegration{name: "metrics-failed", err: errors.New("failed")}
+	err = reconciler.runIntegration(ctx, itg, route, integrations.IngressInfo{}, nil, logger)
+	require.NotNil(t, err)
+	assert.Equal(t, 1.0, testutil.ToFloat64(integrationErrors.WithLabelValues("metrics-failed")))
+
+	// Failures to resolve targets should be counted
+	itg = &metricsIntegration{name: "metrics-targets", targetsErr: errors.New("failed")}
+	err = reconciler.runIntegration(ctx, itg, route, integrations.IngressInfo{}, nil, logger)
+	require.Nil(t, err)
+	assert.Equal(t,
+		1.0, testutil.ToFloat64(targetResolutionErrors.WithLabelValues("metrics-targets")),
+	)
+}
+
+//-------------------------------------------------------------------------------------------------
+// UTILS
+//-------------------------------------------------------------------------------------------------
+
+type metricsIntegration struct {
+	name       string
+	err        error
+	targets    []string
+	targetsErr error
+}
+
+func (i *metricsIntegration) Name() string {
+	return i.name
+}
+
+func (*metricsIntegration) OwnedResource() client.Object {
+	return nil
+}
+
+func (*metricsIntegration) Watches() []k8s.Watch {
+	return nil
+}
+
+func (i *metricsIntegration) UpdateResource(
+	context.Context, metav1.Object, integrations.IngressInfo,
+) error {
+	return i.err
+}
+
+func (i *metricsIntegration) ResolveTargets(context.Context) ([]string, error) {
+	return i.targets, i.targetsErr
+}
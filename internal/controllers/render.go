@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	configv1 "github.com/borchero/switchboard/internal/config/v1"
+	"github.com/borchero/switchboard/internal/integrations"
+	"github.com/borchero/switchboard/internal/switchboard"
+	traefik "github.com/traefik/traefik/v3/pkg/provider/kubernetes/crd/traefikio/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// Render computes the resources that the integrations would generate for all ingress routes
+// among the given objects without connecting to a cluster. All other objects (e.g. services that
+// targets are resolved from) are only made available to the integrations. Integrations which do
+// not manage Kubernetes resources are skipped and dependencies are not waited for.
+func Render(
+	ctx context.Context, config configv1.Config, scheme *runtime.Scheme, objects []client.Object,
+) ([]client.Object, error) {
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+	itgs, err := integrationsFromConfig(config, c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize integrations: %s", err)
+	}
+	selector := switchboard.NewSelector(config.Selector.IngressClass)
+
+	var result []client.Object
+	for _, obj := range objects {
+		route, ok := obj.(*traefik.IngressRoute)
+		if !ok || !selector.Matches(route.Annotations) {
+			continue
+		}
+		info, err := ingressInfo(route)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failed to parse hosts from ingress route %s/%s: %s",
+				route.Namespace, route.Name, err,
+			)
+		}
+		for _, itg := range itgs {
+			if itg.OwnedResource() == nil || !selector.MatchesIntegration(
+				route.Annotations, itg.Name(),
+			) {
+				continue
+			}
+			itgCtx := integrations.WithChangeHandler(
+				ctx, func(_ context.Context, change integrations.Change) {
+					if change.Operation != integrations.OperationResultDeleted {
+						result = append(result, change.Object)
+					}
+				},
+			)
+			if err := itg.UpdateResource(itgCtx, route, info); err != nil {
+				return nil, fmt.Errorf(
+					"integration %s failed for ingress route %s/%s: %w",
+					itg.Name(), route.Namespace, route.Name, err,
+				)
+			}
+		}
+	}
+
+	// Eventually, we make the resources self-describing and drop server-side fields
+	for _, obj := range result {
+		if gvk, err := apiutil.GVKForObject(obj, scheme); err == nil {
+			obj.GetObjectKind().SetGroupVersionKind(gvk)
+		}
+		obj.SetResourceVersion("")
+	}
+	return result, nil
+}
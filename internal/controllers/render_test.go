@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	configv1 "github.com/borchero/switchboard/internal/config/v1"
+	"github.com/borchero/switchboard/internal/k8tests"
+	certmanager "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	traefik "github.com/traefik/traefik/v3/pkg/provider/kubernetes/crd/traefikio/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	externaldnsv1alpha1 "sigs.k8s.io/external-dns/apis/v1alpha1"
+)
+
+func TestRender(t *testing.T) {
+	// Setup
+	ctx := context.Background()
+	scheme := k8tests.NewScheme()
+	var config configv1.Config
+	config.Integrations.ExternalDNS = &configv1.ExternalDNSIntegrationConfig{
+		TargetConfig: configv1.TargetConfig{
+			TargetService: &configv1.ServiceRef{Name: "traefik", Namespace: "kube-system"},
+		},
+	}
+	config.Integrations.CertManager = &configv1.CertManagerIntegrationConfig{
+		Template: certmanager.Certificate{Spec: certmanager.CertificateSpec{
+			IssuerRef: cmmeta.IssuerReference{Kind: "ClusterIssuer", Name: "my-issuer"},
+		}},
+	}
+	service := k8tests.DummyService("traefik", "kube-system", 80)
+	service.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{IP: "10.0.0.1"}}
+	route := &traefik.IngressRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-route", Namespace: "default"},
+		Spec: traefik.IngressRouteSpec{
+			Routes: []traefik.Route{{Kind: "Rule", Match: "Host(`www.example.com`)"}},
+			TLS:    &traefik.TLS{SecretName: "www-tls"},
+		},
+	}
+
+	// Resources of all integrations should be rendered
+	resources, err := Render(ctx, config, scheme, []client.Object{route, &service})
+	require.Nil(t, err)
+	require.Len(t, resources, 2)
+
+	endpoint, ok := resources[0].(*externaldnsv1alpha1.DNSEndpoint)
+	require.True(t, ok)
+	assert.Equal(t, "DNSEndpoint", endpoint.Kind)
+	assert.Equal(t, "my-route", endpoint.Name)
+	assert.Empty(t, endpoint.ResourceVersion)
+	assert.Equal(t, []string{"10.0.0.1"}, []string(endpoint.Spec.Endpoints[0].Targets))
+
+	certificate, ok := resources[1].(*certmanager.Certificate)
+	require.True(t, ok)
+	assert.Equal(t, "my-route-tls", certificate.Name)
+	assert.Equal(t, []string{"www.example.com"}, certificate.Spec.DNSNames)
+
+	// Ignored integrations should not be rendered
+	route.Annotations = map[string]string{"switchboard.borchero.com/ignore": "external-dns"}
+	resources, err = Render(ctx, config, scheme, []client.Object{route, &service})
+	require.Nil(t, err)
+	require.Len(t, resources, 1)
+	assert.Equal(t, "Certificate", resources[0].GetObjectKind().GroupVersionKind().Kind)
+}
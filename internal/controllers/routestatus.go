@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/borchero/switchboard/internal/api/v1alpha1"
+	"github.com/borchero/switchboard/internal/integrations"
+	traefik "github.com/traefik/traefik/v3/pkg/provider/kubernetes/crd/traefikio/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// routeStatusBuilder collects the status of an ingress route while its integrations are run. All
+// methods are safe for concurrent use and may be called on a nil builder, in which case they do
+// nothing.
+type routeStatusBuilder struct {
+	mutex  sync.Mutex
+	scheme *runtime.Scheme
+	status v1alpha1.RouteStatusStatus
+}
+
+// newRouteStatusBuilder initializes a new builder for the given ingress route. The integrations are
+// reported in the order of the given names, regardless of the order in which they finish.
+func newRouteStatusBuilder(
+	scheme *runtime.Scheme, route *traefik.IngressRoute, hosts []string, names []string,
+) *routeStatusBuilder {
+	statuses := make([]v1alpha1.IntegrationStatus, 0, len(names))
+	for _, name := range names {
+		statuses = append(statuses, v1alpha1.IntegrationStatus{Name: name})
+	}
+	return &routeStatusBuilder{
+		scheme: scheme,
+		status: v1alpha1.RouteStatusStatus{
+			ObservedGeneration: route.Generation,
+			Hosts:              hosts,
+			Integrations:       statuses,
+		},
+	}
+}
+
+// addTargets adds the given targets to the status.
+func (b *routeStatusBuilder) addTargets(targets []string) {
+	if b == nil {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for _, target := range targets {
+		if !slices.Contains(b.status.Targets, target) {
+			b.status.Targets = append(b.status.Targets, target)
+		}
+	}
+	slices.Sort(b.status.Targets)
+}
+
+// withResourceTracking returns a context which causes the integration with the given name to
+// record all resources that it manages in the status.
+func (b *routeStatusBuilder) withResourceTracking(
+	ctx context.Context, integration string,
+) context.Context {
+	if b == nil {
+		return ctx
+	}
+	return integrations.WithChangeHandler(
+		ctx, func(_ context.Context, change integrations.Change) {
+			if change.Operation == integrations.OperationResultDeleted {
+				return
+			}
+			b.mutex.Lock()
+			defer b.mutex.Unlock()
+			status := b.integration(integration)
+			ref := b.reference(change.Object)
+			if !slices.Contains(status.Resources, ref) {
+				status.Resources = append(status.Resources, ref)
+			}
+		},
+	)
+}
+
+func (b *routeStatusBuilder) ignored(integration string) {
+	b.setState(integration, v1alpha1.IntegrationStateIgnored, "")
+}
+
+func (b *routeStatusBuilder) ready(integration string) {
+	b.setState(integration, v1alpha1.IntegrationStateReady, "")
+}
+
+func (b *routeStatusBuilder) waiting(integration string, message string) {
+	b.setState(integration, v1alpha1.IntegrationStateWaiting, message)
+}
+
+func (b *routeStatusBuilder) failed(integration string, err error) {
+	b.setState(integration, v1alpha1.IntegrationStateError, err.Error())
+}
+
+//-------------------------------------------------------------------------------------------------
+// PERSISTENCE
+//-------------------------------------------------------------------------------------------------
+
+// newRouteStatus returns a builder for the route status of the given ingress route or nil if the
+// route status is disabled.
+func (r *IngressRouteReconciler) newRouteStatus(
+	route *traefik.IngressRoute, hosts []string,
+) *routeStatusBuilder {
+	if !r.routeStatus {
+		return nil
+	}
+	names := make([]string, 0, len(r.integrations))
+	for _, itg := range r.integrations {
+		names = append(names, itg.Name())
+	}
+	return newRouteStatusBuilder(r.Scheme(), route, hosts, names)
+}
+
+// updateRouteStatus writes the collected status to the route status of the given ingress route,
+// creating the route status if it does not exist yet.
+func (r *IngressRouteReconciler) updateRouteStatus(
+	ctx context.Context, route *traefik.IngressRoute, builder *routeStatusBuilder,
+) error {
+	if builder == nil {
+		return nil
+	}
+
+	var routeStatus v1alpha1.RouteStatus
+	name := types.NamespacedName{Name: route.Name, Namespace: route.Namespace}
+	if err := r.Get(ctx, name, &routeStatus); err != nil {
+		if !apierrs.IsNotFound(err) {
+			return fmt.Errorf("failed to query route status: %w", err)
+		}
+		routeStatus = v1alpha1.RouteStatus{ObjectMeta: metav1.ObjectMeta{
+			Name:      route.Name,
+			Namespace: route.Namespace,
+			Labels:    map[string]string{"kubernetes.io/managed-by": "switchboard"},
+		}}
+		if err := ctrl.SetControllerReference(route, &routeStatus, r.Scheme()); err != nil {
+			return fmt.Errorf("failed to set owner of route status: %s", err)
+		}
+		if err := r.Create(ctx, &routeStatus); err != nil {
+			return fmt.Errorf("failed to create route status: %w", err)
+		}
+	}
+
+	if equality.Semantic.DeepEqual(routeStatus.Status, builder.status) {
+		return nil
+	}
+	routeStatus.Status = builder.status
+	if err := r.Status().Update(ctx, &routeStatus); err != nil {
+		return fmt.Errorf("failed to update route status: %w", err)
+	}
+	return nil
+}
+
+// deleteRouteStatus removes the route status of the given ingress route if it exists.
+func (r *IngressRouteReconciler) deleteRouteStatus(
+	ctx context.Context, route *traefik.IngressRoute,
+) error {
+	if !r.routeStatus {
+		return nil
+	}
+	var routeStatus v1alpha1.RouteStatus
+	name := types.NamespacedName{Name: route.Name, Namespace: route.Namespace}
+	if err := r.Get(ctx, name, &routeStatus); err != nil {
+		if apierrs.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to query route status: %w", err)
+	}
+	if err := r.Delete(ctx, &routeStatus); err != nil && !apierrs.IsNotFound(err) {
+		return fmt.Errorf("failed to delete route status: %w", err)
+	}
+	return nil
+}
+
+//-------------------------------------------------------------------------------------------------
+// UTILS
+//-------------------------------------------------------------------------------------------------
+
+func (b *routeStatusBuilder) integration(name string) *v1alpha1.IntegrationStatus {
+	for i := range b.status.Integrations {
+		if b.status.Integrations[i].Name == name {
+			return &b.status.Integrations[i]
+		}
+	}
+	b.status.Integrations = append(b.status.Integrations, v1alpha1.IntegrationStatus{Name: name})
+	return &b.status.Integrations[len(b.status.Integrations)-1]
+}
+
+func (b *routeStatusBuilder) setState(
+	integration string, state v1alpha1.IntegrationState, message string,
+) {
+	if b == nil {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	status := b.integration(integration)
+	status.State = state
+	status.Message = message
+}
+
+func (b *routeStatusBuilder) reference(obj client.Object) v1alpha1.ResourceReference {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	if gvk.Empty() {
+		if resolved, err := apiutil.GVKForObject(obj, b.scheme); err == nil {
+			gvk = resolved
+		}
+	}
+	return v1alpha1.ResourceReference{
+		APIVersion: gvk.GroupVersion().String(),
+		Kind:       gvk.Kind,
+		Namespace:  obj.GetNamespace(),
+		Name:       obj.GetName(),
+	}
+}
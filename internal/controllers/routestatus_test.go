@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/borchero/switchboard/internal/api/v1alpha1"
+	"github.com/borchero/switchboard/internal/integrations"
+	"github.com/borchero/switchboard/internal/k8tests"
+	"github.com/borchero/switchboard/internal/switchboard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	traefik "github.com/traefik/traefik/v3/pkg/provider/kubernetes/crd/traefikio/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRouteStatusBuilder(t *testing.T) {
+	route := &traefik.IngressRoute{ObjectMeta: metav1.ObjectMeta{Name: "my-route", Generation: 3}}
+	builder := newRouteStatusBuilder(
+		k8tests.NewScheme(), route, []string{"example.com"},
+		[]string{"external-dns", "cert-manager", "probe"},
+	)
+
+	builder.addTargets([]string{"10.0.0.2", "10.0.0.1"})
+	builder.addTargets([]string{"10.0.0.1"})
+	builder.ignored("probe")
+	builder.failed("cert-manager", fmt.Errorf("failure"))
+	builder.ready("external-dns")
+	builder.ready("cert-manager")
+
+	assert.Equal(t, v1alpha1.RouteStatusStatus{
+		ObservedGeneration: 3,
+		Hosts:              []string{"example.com"},
+		Targets:            []string{"10.0.0.1", "10.0.0.2"},
+		Integrations: []v1alpha1.IntegrationStatus{
+			{Name: "external-dns", State: v1alpha1.IntegrationStateReady},
+			{Name: "cert-manager", State: v1alpha1.IntegrationStateReady},
+			{Name: "probe", State: v1alpha1.IntegrationStateIgnored},
+		},
+	}, builder.status)
+
+	// References should resolve the kind of typed objects
+	configMap := &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "my-config", Namespace: "ns"}}
+	assert.Equal(t, v1alpha1.ResourceReference{
+		APIVersion: "v1", Kind: "ConfigMap", Namespace: "ns", Name: "my-config",
+	}, builder.reference(configMap))
+
+	// A nil builder should do nothing
+	var nilBuilder *routeStatusBuilder
+	nilBuilder.ready("external-dns")
+	nilBuilder.addTargets([]string{"10.0.0.1"})
+}
+
+func TestRouteStatusLifecycle(t *testing.T) {
+	ctx := context.Background()
+	route := &traefik.IngressRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "my-route",
+			Namespace:   "default",
+			Annotations: map[string]string{"kubernetes.io/ingress.class": "traefik"},
+		},
+		Spec: traefik.IngressRouteSpec{
+			Routes: []traefik.Route{{Kind: "Rule", Match: "Host(`example.com`)"}},
+		},
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(k8tests.NewScheme()).
+		WithObjects(route).
+		WithStatusSubresource(&v1alpha1.RouteStatus{}).
+		Build()
+	ingressClass := "traefik"
+	r := IngressRouteReconciler{
+		Client:       c,
+		logger:       slog.New(slog.DiscardHandler),
+		selector:     switchboard.NewSelector(&ingressClass),
+		integrations: []integrations.Integration{&metricsIntegration{name: "status"}},
+		routeStatus:  true,
+		waits:        newDependencyWaits(),
+	}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(route)}
+	getStatus := func() (v1alpha1.RouteStatus, error) {
+		var routeStatus v1alpha1.RouteStatus
+		err := c.Get(ctx, req.NamespacedName, &routeStatus)
+		return routeStatus, err
+	}
+
+	// Selected routes should get a route status
+	_, err := r.Reconcile(ctx, req)
+	require.Nil(t, err)
+	routeStatus, err := getStatus()
+	require.Nil(t, err)
+	assert.Equal(t, []string{"example.com"}, routeStatus.Status.Hosts)
+	assert.Equal(t, v1alpha1.IntegrationStateReady, routeStatus.Status.Integrations[0].State)
+
+	// Routes whose hosts cannot be parsed should report an error
+	route.Spec.Routes[0].Match = "Host(`example.com`"
+	require.Nil(t, c.Update(ctx, route))
+	_, err = r.Reconcile(ctx, req)
+	require.NotNil(t, err)
+	routeStatus, err = getStatus()
+	require.Nil(t, err)
+	assert.Empty(t, routeStatus.Status.Hosts)
+	assert.Equal(t, v1alpha1.IntegrationStateError, routeStatus.Status.Integrations[0].State)
+	assert.Contains(t, routeStatus.Status.Integrations[0].Message, "failed to parse hosts")
+
+	// Routes which are not selected anymore should not have a route status
+	route.Annotations["kubernetes.io/ingress.class"] = "other"
+	require.Nil(t, c.Update(ctx, route))
+	_, err = r.Reconcile(ctx, req)
+	require.Nil(t, err)
+	_, err = getStatus()
+	assert.True(t, apierrs.IsNotFound(err))
+}
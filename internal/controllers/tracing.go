@@ -0,0 +1,24 @@
+package controllers
+
+import (
+	"github.com/borchero/switchboard/internal/tracing"
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	attributeRouteNamespace = "switchboard.route.namespace"
+	attributeRouteName      = "switchboard.route.name"
+	attributeHosts          = "switchboard.hosts"
+	attributeTargets        = "switchboard.targets"
+	attributeIntegration    = "switchboard.integration"
+	attributeResult         = "switchboard.result"
+
+	resultReady   = "ready"
+	resultWaiting = "waiting"
+	resultError   = "error"
+	resultDeleted = "deleted"
+)
+
+// tracer creates the spans of reconciliations. It uses the global tracer provider which does not
+// record any spans unless tracing is configured.
+var tracer = tracing.Tracer(otel.GetTracerProvider())
@@ -1,8 +1,15 @@
 package controllers
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
+	"net/url"
+	"os"
+	"path"
+	"reflect"
+	"slices"
+	"strings"
 
 	configv1 "github.com/borchero/switchboard/internal/config/v1"
 	"github.com/borchero/switchboard/internal/ext"
@@ -10,9 +17,14 @@ import (
 	"github.com/borchero/switchboard/internal/k8s"
 	"github.com/borchero/switchboard/internal/switchboard"
 	traefik "github.com/traefik/traefik/v3/pkg/provider/kubernetes/crd/traefikio/v1alpha1"
-	"sigs.k8s.io/controller-runtime/pkg/builder"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrlbuilder "sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 func integrationsFromConfig(
@@ -21,59 +33,398 @@ func integrationsFromConfig(
 	result := make([]integrations.Integration, 0)
 	externalDNS := config.Integrations.ExternalDNS
 	if config.Integrations.ExternalDNS != nil {
-		if (externalDNS.TargetService == nil) == (len(externalDNS.TargetIPs) == 0) {
-			return nil, fmt.Errorf(
-				"exactly one of `targetService` and `targetIPs` must be set for external-dns",
-			)
+		target, err := targetFromConfig(externalDNS.TargetConfig)
+		if err != nil {
+			return nil, fmt.Errorf("invalid target for external-dns: %s", err)
+		}
+		options := make([]integrations.ExternalDNSOption, 0)
+		for key := range externalDNS.CAARecords {
+			if kind, name, ok := strings.Cut(key, "/"); !ok || kind == "" || name == "" {
+				return nil, fmt.Errorf(
+					"invalid issuer %q for CAA records of external-dns, must be <kind>/<name>", key,
+				)
+			}
+		}
+		if certManager := config.Integrations.CertManager; certManager != nil {
+			if records, ok := externalDNS.CAARecords[issuerKey(certManager)]; ok {
+				options = append(options, integrations.WithCAARecords(records...))
+			}
 		}
-		if externalDNS.TargetService != nil {
-			result = append(result, integrations.NewExternalDNS(
-				client, switchboard.NewServiceTarget(
-					externalDNS.TargetService.Name,
-					externalDNS.TargetService.Namespace,
-				),
-				externalDNS.TTL,
+		if httpsRecords := externalDNS.HTTPSRecords; httpsRecords != nil {
+			if len(httpsRecords.EntryPoints) == 0 {
+				return nil, fmt.Errorf(
+					"at least one entry point must be set for HTTPS records of external-dns",
+				)
+			}
+			options = append(options, integrations.WithHTTPSRecords(
+				httpsRecords.EntryPoints, httpsRecords.ALPN...,
 			))
-		} else {
-			result = append(result, integrations.NewExternalDNS(
-				client, switchboard.NewStaticTarget(externalDNS.TargetIPs...),
-				externalDNS.TTL,
+		}
+		if extraRecords := externalDNS.ExtraRecords; extraRecords != nil {
+			options = append(options, integrations.WithExtraRecords(
+				extraRecords.AllowedTypes...,
 			))
 		}
+		result = append(result, integrations.NewExternalDNS(
+			client, target, externalDNS.TTL, options...,
+		))
 	}
 
 	certManager := config.Integrations.CertManager
 	if certManager != nil {
-		result = append(result, integrations.NewCertManager(client, certManager.Template))
+		result = append(result, integrations.NewCertManager(
+			client, certManager.Template,
+			integrations.WithCertManagerDependencies(certManager.DependsOn...),
+		))
+	}
+
+	rfc2136 := config.Integrations.RFC2136
+	if rfc2136 != nil {
+		target, err := targetFromConfig(rfc2136.TargetConfig)
+		if err != nil {
+			return nil, fmt.Errorf("invalid target for rfc2136: %s", err)
+		}
+		if rfc2136.Address == "" || rfc2136.Zone == "" {
+			return nil, fmt.Errorf("`address` and `zone` must be set for rfc2136")
+		}
+		server := integrations.RFC2136Server{
+			Address:       rfc2136.Address,
+			Zone:          rfc2136.Zone,
+			TSIGKeyName:   rfc2136.TSIGKeyName,
+			TSIGAlgorithm: rfc2136.TSIGAlgorithm,
+		}
+		if rfc2136.TSIGSecretEnv != "" {
+			secret, ok := os.LookupEnv(rfc2136.TSIGSecretEnv)
+			if !ok {
+				return nil, fmt.Errorf(
+					"environment variable %q for TSIG secret of rfc2136 is not set",
+					rfc2136.TSIGSecretEnv,
+				)
+			}
+			server.TSIGSecret = secret
+		}
+		ownerID := rfc2136.OwnerID
+		if ownerID == "" {
+			ownerID = "default"
+		}
+		result = append(result, integrations.NewRFC2136(
+			client, target, server, ownerID, rfc2136.TTL,
+		))
 	}
-	return result, nil
+
+	coreDNS := config.Integrations.CoreDNS
+	if coreDNS != nil {
+		services := serviceRefsFromConfig(coreDNS.TargetService, coreDNS.TargetServices)
+		if len(services) == 0 {
+			return nil, fmt.Errorf("`targetService(s)` must be set for coredns")
+		}
+		selectors, err := serviceSelectorsFromConfig(services)
+		if err != nil {
+			return nil, fmt.Errorf("invalid target service for coredns: %s", err)
+		}
+		configMap := types.NamespacedName{Name: "coredns-custom", Namespace: "kube-system"}
+		if coreDNS.ConfigMap != nil {
+			configMap = types.NamespacedName{
+				Name: coreDNS.ConfigMap.Name, Namespace: coreDNS.ConfigMap.Namespace,
+			}
+		}
+		result = append(result, integrations.NewCoreDNS(
+			client, switchboard.NewClusterIPServicesTarget(selectors...), configMap, coreDNS.TTL,
+		))
+	}
+
+	redirect := config.Integrations.Redirect
+	if redirect != nil {
+		if redirect.Middleware.Name == "" {
+			return nil, fmt.Errorf("`middleware.name` must be set for redirect")
+		}
+		result = append(result, integrations.NewRedirect(
+			client, redirect.EntryPoint, traefik.MiddlewareRef{
+				Name:      redirect.Middleware.Name,
+				Namespace: redirect.Middleware.Namespace,
+			},
+		))
+	}
+
+	tlsStore := config.Integrations.TLSStore
+	if tlsStore != nil {
+		if config.Integrations.CertManager == nil {
+			return nil, fmt.Errorf("tls-store requires the cert-manager integration")
+		}
+		if tlsStore.Namespace == "" {
+			return nil, fmt.Errorf("`namespace` must be set for tls-store")
+		}
+		mode := switchboard.TLSStoreModeNone
+		if tlsStore.Mode != "" {
+			parsed, err := switchboard.ParseTLSStoreMode(tlsStore.Mode)
+			if err != nil {
+				return nil, fmt.Errorf("invalid mode for tls-store: %s", err)
+			}
+			mode = parsed
+		}
+		result = append(result, integrations.NewTLSStore(
+			client, tlsStore.Namespace, mode, integrations.WithTLSOptions(tlsStore.Options),
+		))
+	}
+
+	probe := config.Integrations.Probe
+	if probe != nil {
+		if probe.ProberURL == "" {
+			return nil, fmt.Errorf("`proberURL` must be set for probe")
+		}
+		result = append(result, integrations.NewProbe(
+			client, probe.ProberURL, probe.Module, probe.Labels,
+		))
+	}
+
+	reflector := config.Integrations.Reflector
+	if reflector != nil {
+		if config.Integrations.CertManager == nil {
+			return nil, fmt.Errorf("reflector requires the cert-manager integration")
+		}
+		for _, pattern := range reflector.AllowedNamespaces {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid allowed namespace %q for reflector: %s", pattern, err)
+			}
+		}
+		result = append(result, integrations.NewReflector(client, reflector.AllowedNamespaces))
+	}
+
+	webhook := config.Integrations.Webhook
+	if webhook != nil {
+		if webhook.URL == "" {
+			return nil, fmt.Errorf("`url` must be set for webhook")
+		}
+		if _, err := url.ParseRequestURI(webhook.URL); err != nil {
+			return nil, fmt.Errorf("invalid url for webhook: %s", err)
+		}
+		if webhook.State.Namespace == "" {
+			return nil, fmt.Errorf("`state.namespace` must be set for webhook")
+		}
+		var target switchboard.Target
+		if !reflect.DeepEqual(webhook.TargetConfig, configv1.TargetConfig{}) {
+			t, err := targetFromConfig(webhook.TargetConfig)
+			if err != nil {
+				return nil, fmt.Errorf("invalid target for webhook: %s", err)
+			}
+			target = t
+		}
+		var secret []byte
+		if webhook.SecretEnv != "" {
+			value, ok := os.LookupEnv(webhook.SecretEnv)
+			if !ok {
+				return nil, fmt.Errorf(
+					"environment variable %q for secret of webhook is not set", webhook.SecretEnv,
+				)
+			}
+			secret = []byte(value)
+		}
+		state := types.NamespacedName{
+			Name: webhook.State.Name, Namespace: webhook.State.Namespace,
+		}
+		if state.Name == "" {
+			state.Name = "switchboard-webhook"
+		}
+		var options []integrations.WebhookOption
+		if webhook.MaxRetries != nil {
+			if *webhook.MaxRetries < 0 {
+				return nil, fmt.Errorf("`maxRetries` must not be negative for webhook")
+			}
+			options = append(options, integrations.WithWebhookRetries(*webhook.MaxRetries, 0))
+		}
+		result = append(result, integrations.NewWebhook(
+			client, target, webhook.URL, secret, state, options...,
+		))
+	}
+
+	for _, tmpl := range config.Integrations.Templates {
+		if errs := validation.IsDNS1123Label(tmpl.Name); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid name %q for template: %s", tmpl.Name, errs[0])
+		}
+		if slices.ContainsFunc(result, func(itg integrations.Integration) bool {
+			return itg.Name() == tmpl.Name
+		}) {
+			return nil, fmt.Errorf("duplicate integration name %q for template", tmpl.Name)
+		}
+		gvk, err := tmpl.GroupVersionKind()
+		if err != nil {
+			return nil, fmt.Errorf("invalid kind for template %q: %s", tmpl.Name, err)
+		}
+		integration, err := integrations.NewTemplate(client, tmpl.Name, gvk, tmpl.Template)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template %q: %s", tmpl.Name, err)
+		}
+		result = append(result, integration)
+	}
+	return sortIntegrations(result)
+}
+
+// ClientOptions returns the options for the client of the manager. Integrations read single config
+// maps (e.g. the CoreDNS config map) and secrets (i.e. TLS secrets for the reflector) which must
+// not be served from the cache: caching them would require cluster-wide informers holding all
+// config maps and secrets, and reads right after writes (or upon conflicts) would return stale
+// objects.
+func ClientOptions() client.Options {
+	return client.Options{Cache: &client.CacheOptions{
+		DisableFor: []client.Object{&v1.ConfigMap{}, &v1.Secret{}},
+	}}
+}
+
+// IntegrationNames returns the names of all integrations that are enabled by the given config.
+func IntegrationNames(config configv1.Config) ([]string, error) {
+	integrations, err := integrationsFromConfig(config, nil)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(integrations))
+	for _, itg := range integrations {
+		names = append(names, itg.Name())
+	}
+	return names, nil
+}
+
+func targetFromConfig(config configv1.TargetConfig) (switchboard.Target, error) {
+	target, err := unfilteredTargetFromConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	if config.TargetFilter != nil {
+		filter, err := switchboard.NewTargetFilter(
+			config.TargetFilter.IPFamilyPolicy,
+			config.TargetFilter.AllowCIDRs,
+			config.TargetFilter.DenyCIDRs,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("invalid target filter: %s", err)
+		}
+		target = switchboard.NewFilteredTarget(target, filter)
+	}
+	return target, nil
+}
+
+func unfilteredTargetFromConfig(config configv1.TargetConfig) (switchboard.Target, error) {
+	services := serviceRefsFromConfig(config.TargetService, config.TargetServices)
+
+	numTargets := 0
+	for _, isSet := range []bool{
+		len(services) > 0, len(config.TargetIPs) > 0, config.TargetNodes != nil,
+	} {
+		if isSet {
+			numTargets++
+		}
+	}
+	if numTargets != 1 {
+		return nil, fmt.Errorf(
+			"exactly one of `targetService(s)`, `targetIPs` and `targetNodes` must be set",
+		)
+	}
+
+	switch {
+	case len(services) > 0:
+		selectors, err := serviceSelectorsFromConfig(services)
+		if err != nil {
+			return nil, fmt.Errorf("invalid target service: %s", err)
+		}
+		return switchboard.NewServicesTarget(selectors...), nil
+	case config.TargetNodes != nil:
+		selector, err := metav1.LabelSelectorAsSelector(&config.TargetNodes.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node selector: %s", err)
+		}
+		return switchboard.NewNodeTarget(selector, config.TargetNodes.AddressTypes...), nil
+	default:
+		return switchboard.NewStaticTarget(config.TargetIPs...), nil
+	}
+}
+
+// issuerKey returns the key of the issuer that the cert-manager integration uses for looking up CAA
+// records. Just like in cert-manager, the kind of the issuer defaults to `Issuer`.
+func issuerKey(config *configv1.CertManagerIntegrationConfig) string {
+	issuerRef := config.Template.Spec.IssuerRef
+	kind := issuerRef.Kind
+	if kind == "" {
+		kind = "Issuer"
+	}
+	return kind + "/" + issuerRef.Name
+}
+
+func serviceRefsFromConfig(
+	service *configv1.ServiceRef, services []configv1.ServiceRef,
+) []configv1.ServiceRef {
+	if service == nil {
+		return services
+	}
+	return append([]configv1.ServiceRef{*service}, services...)
+}
+
+func serviceSelectorsFromConfig(
+	refs []configv1.ServiceRef,
+) ([]switchboard.ServiceSelector, error) {
+	selectors := make([]switchboard.ServiceSelector, 0, len(refs))
+	for _, ref := range refs {
+		selector, err := serviceSelectorFromConfig(ref)
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, selector)
+	}
+	return selectors, nil
+}
+
+func serviceSelectorFromConfig(ref configv1.ServiceRef) (switchboard.ServiceSelector, error) {
+	if (ref.Name == "") == (ref.Selector == nil) {
+		return switchboard.ServiceSelector{}, fmt.Errorf(
+			"exactly one of `name` and `selector` must be set",
+		)
+	}
+	selector := switchboard.ServiceSelector{Name: ref.Name, Namespace: ref.Namespace}
+	if ref.Selector != nil {
+		labels, err := metav1.LabelSelectorAsSelector(ref.Selector)
+		if err != nil {
+			return switchboard.ServiceSelector{}, err
+		}
+		selector.Labels = labels
+	}
+	return selector, nil
 }
 
 func builderWithIntegrations(
-	builder *builder.Builder,
+	builder *ctrlbuilder.Builder,
 	integrations []integrations.Integration,
 	ctrlClient client.Client,
 	logger *slog.Logger,
-) *builder.Builder {
+) *ctrlbuilder.Builder {
 	// Reconcile whenever an owned resource of one of the integrations is modified
 	for _, itg := range integrations {
-		builder = builder.Owns(itg.OwnedResource())
+		if itg.OwnedResource() != nil {
+			builder = builder.Owns(itg.OwnedResource())
+		}
 	}
 
 	// Watch for dependent resources if required
 	for _, itg := range integrations {
-		if itg.WatchedObject() != nil {
+		for _, watch := range itg.Watches() {
 			var list traefik.IngressRouteList
-			enqueue := k8s.EnqueueMapFunc(ctrlClient, logger, itg.WatchedObject(), &list,
+			enqueue := k8s.EnqueueAllMapFunc(ctrlClient, logger, &list,
 				func(list *traefik.IngressRouteList) []client.Object {
 					return ext.Map(list.Items, func(v traefik.IngressRoute) client.Object {
 						return &v
 					})
 				},
 			)
+			if watch.Map != nil {
+				enqueue = func(ctx context.Context, obj client.Object) []reconcile.Request {
+					requests, err := watch.Map(ctx, obj)
+					if err != nil {
+						logger.Error("failed to map object change to ingress routes", "error", err)
+					}
+					return requests
+				}
+			}
 			builder = builder.Watches(
-				itg.WatchedObject(),
+				watch.Object,
 				handler.EnqueueRequestsFromMapFunc(enqueue),
+				ctrlbuilder.WithPredicates(watch.Predicates...),
 			)
 		}
 	}
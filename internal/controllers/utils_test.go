@@ -9,6 +9,8 @@ import (
 	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestIntegrationsFromConfig(t *testing.T) {
@@ -24,7 +26,9 @@ func TestIntegrationsFromConfig(t *testing.T) {
 	assert.Len(t, integrations, 0)
 
 	config.Integrations.ExternalDNS = &configv1.ExternalDNSIntegrationConfig{
-		TargetService: &configv1.ServiceRef{Name: "my-service", Namespace: "my-namespace"},
+		TargetConfig: configv1.TargetConfig{
+			TargetService: &configv1.ServiceRef{Name: "my-service", Namespace: "my-namespace"},
+		},
 	}
 	integrations, err = integrationsFromConfig(config, client)
 	require.Nil(t, err)
@@ -48,7 +52,9 @@ func TestIntegrationsFromConfig(t *testing.T) {
 	assert.Equal(t, "cert-manager", integrations[0].Name())
 
 	config.Integrations.ExternalDNS = &configv1.ExternalDNSIntegrationConfig{
-		TargetIPs: []string{"127.0.0.1"},
+		TargetConfig: configv1.TargetConfig{
+			TargetIPs: []string{"127.0.0.1"},
+		},
 	}
 	integrations, err = integrationsFromConfig(config, client)
 	require.Nil(t, err)
@@ -60,19 +66,335 @@ func TestIntegrationsFromConfig(t *testing.T) {
 	require.NotNil(t, err)
 
 	config.Integrations.ExternalDNS = &configv1.ExternalDNSIntegrationConfig{
-		TargetIPs: []string{},
+		TargetConfig: configv1.TargetConfig{
+			TargetIPs: []string{},
+		},
+	}
+	_, err = integrationsFromConfig(config, client)
+	require.NotNil(t, err)
+
+	config.Integrations.ExternalDNS = &configv1.ExternalDNSIntegrationConfig{
+		TargetConfig: configv1.TargetConfig{
+			TargetIPs:   []string{"127.0.0.1"},
+			TargetNodes: &configv1.NodeTargetConfig{},
+		},
+	}
+	_, err = integrationsFromConfig(config, client)
+	require.NotNil(t, err)
+
+	// Multiple services can be used as targets
+	config.Integrations.ExternalDNS = &configv1.ExternalDNSIntegrationConfig{
+		TargetConfig: configv1.TargetConfig{
+			TargetService: &configv1.ServiceRef{Name: "my-service", Namespace: "my-namespace"},
+			TargetServices: []configv1.ServiceRef{{
+				Namespace: "my-namespace",
+				Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"app.kubernetes.io/name": "traefik"},
+				},
+			}},
+		},
+	}
+	integrations, err = integrationsFromConfig(config, client)
+	require.Nil(t, err)
+	assert.Len(t, integrations, 2)
+	assert.Len(t, integrations[0].Watches(), 2)
+
+	config.Integrations.ExternalDNS = &configv1.ExternalDNSIntegrationConfig{
+		TargetConfig: configv1.TargetConfig{
+			TargetServices: []configv1.ServiceRef{{Namespace: "my-namespace"}},
+		},
+	}
+	_, err = integrationsFromConfig(config, client)
+	require.NotNil(t, err)
+
+	config.Integrations.ExternalDNS = &configv1.ExternalDNSIntegrationConfig{
+		TargetConfig: configv1.TargetConfig{
+			TargetServices: []configv1.ServiceRef{{Name: "my-service", Namespace: "my-namespace"}},
+			TargetIPs:      []string{"127.0.0.1"},
+		},
 	}
 	_, err = integrationsFromConfig(config, client)
 	require.NotNil(t, err)
 
+	// Nodes can be used as targets
+	config.Integrations.ExternalDNS = &configv1.ExternalDNSIntegrationConfig{
+		TargetConfig: configv1.TargetConfig{
+			TargetNodes: &configv1.NodeTargetConfig{
+				Selector: metav1.LabelSelector{
+					MatchLabels: map[string]string{"node-role.kubernetes.io/ingress": "true"},
+				},
+			},
+		},
+	}
+	integrations, err = integrationsFromConfig(config, client)
+	require.Nil(t, err)
+	assert.Len(t, integrations, 2)
+
+	config.Integrations.ExternalDNS = &configv1.ExternalDNSIntegrationConfig{
+		TargetConfig: configv1.TargetConfig{
+			TargetNodes: &configv1.NodeTargetConfig{
+				Selector: metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{{
+						Key: "ingress", Operator: "Unknown",
+					}},
+				},
+			},
+		},
+	}
+	_, err = integrationsFromConfig(config, client)
+	require.NotNil(t, err)
+
+	// Must fail if the target filter is invalid
+	config.Integrations.ExternalDNS = &configv1.ExternalDNSIntegrationConfig{
+		TargetConfig: configv1.TargetConfig{
+			TargetIPs:    []string{"127.0.0.1"},
+			TargetFilter: &configv1.TargetFilterConfig{IPFamilyPolicy: "IPv5Only"},
+		},
+	}
+	_, err = integrationsFromConfig(config, client)
+	require.NotNil(t, err)
+
+	config.Integrations.ExternalDNS = &configv1.ExternalDNSIntegrationConfig{
+		TargetConfig: configv1.TargetConfig{
+			TargetIPs:    []string{"127.0.0.1"},
+			TargetFilter: &configv1.TargetFilterConfig{AllowCIDRs: []string{"10.0.0.0"}},
+		},
+	}
+	_, err = integrationsFromConfig(config, client)
+	require.NotNil(t, err)
+
+	config.Integrations.ExternalDNS = &configv1.ExternalDNSIntegrationConfig{
+		TargetConfig: configv1.TargetConfig{
+			TargetIPs:    []string{"127.0.0.1"},
+			TargetFilter: &configv1.TargetFilterConfig{IPFamilyPolicy: "IPv4Only"},
+		},
+	}
+	integrations, err = integrationsFromConfig(config, client)
+	require.Nil(t, err)
+	assert.Len(t, integrations, 2)
+
+	// Must fail if HTTPS records are not configured correctly
+	config.Integrations.ExternalDNS = &configv1.ExternalDNSIntegrationConfig{
+		TargetConfig: configv1.TargetConfig{
+			TargetIPs: []string{"127.0.0.1"},
+		},
+		HTTPSRecords: &configv1.HTTPSRecordsConfig{},
+	}
+	_, err = integrationsFromConfig(config, client)
+	require.NotNil(t, err)
+
+	config.Integrations.ExternalDNS = &configv1.ExternalDNSIntegrationConfig{
+		TargetConfig: configv1.TargetConfig{
+			TargetIPs: []string{"127.0.0.1"},
+		},
+		HTTPSRecords: &configv1.HTTPSRecordsConfig{EntryPoints: []string{"websecure"}},
+	}
+	integrations, err = integrationsFromConfig(config, client)
+	require.Nil(t, err)
+	assert.Len(t, integrations, 2)
+
+	// Test CAA records which must be keyed by kind and name of the issuer
+	config.Integrations.ExternalDNS = &configv1.ExternalDNSIntegrationConfig{
+		TargetConfig: configv1.TargetConfig{TargetIPs: []string{"127.0.0.1"}},
+		CAARecords:   map[string][]string{"letsencrypt": {`0 issue "letsencrypt.org"`}},
+	}
+	_, err = integrationsFromConfig(config, client)
+	require.NotNil(t, err)
+
+	config.Integrations.ExternalDNS.CAARecords = map[string][]string{
+		"ClusterIssuer/letsencrypt": {`0 issue "letsencrypt.org"`},
+	}
+	integrations, err = integrationsFromConfig(config, client)
+	require.Nil(t, err)
+	assert.Len(t, integrations, 2)
+
 	// Test with custom TTL configuration
 	customTTL := int64(3600)
 	config.Integrations.ExternalDNS = &configv1.ExternalDNSIntegrationConfig{
-		TargetService: &configv1.ServiceRef{Name: "my-service", Namespace: "my-namespace"},
-		TTL:           &customTTL,
+		TargetConfig: configv1.TargetConfig{
+			TargetService: &configv1.ServiceRef{Name: "my-service", Namespace: "my-namespace"},
+		},
+		TTL: &customTTL,
+	}
+	integrations, err = integrationsFromConfig(config, client)
+	require.Nil(t, err)
+	assert.Len(t, integrations, 2)
+	assert.Equal(t, "external-dns", integrations[0].Name())
+
+	// Test RFC2136 configuration
+	config.Integrations.RFC2136 = &configv1.RFC2136IntegrationConfig{
+		TargetConfig: configv1.TargetConfig{TargetIPs: []string{"127.0.0.1"}},
+		Address:      "127.0.0.1:53",
+		Zone:         "example.com",
+	}
+	integrations, err = integrationsFromConfig(config, client)
+	require.Nil(t, err)
+	assert.Len(t, integrations, 3)
+	assert.Equal(t, "rfc2136", integrations[2].Name())
+
+	config.Integrations.RFC2136 = &configv1.RFC2136IntegrationConfig{
+		TargetConfig: configv1.TargetConfig{TargetIPs: []string{"127.0.0.1"}},
+		Zone:         "example.com",
+	}
+	_, err = integrationsFromConfig(config, client)
+	require.NotNil(t, err)
+
+	config.Integrations.RFC2136 = &configv1.RFC2136IntegrationConfig{
+		TargetConfig:  configv1.TargetConfig{TargetIPs: []string{"127.0.0.1"}},
+		Address:       "127.0.0.1:53",
+		Zone:          "example.com",
+		TSIGKeyName:   "switchboard",
+		TSIGSecretEnv: "SWITCHBOARD_TEST_UNSET_TSIG_SECRET",
+	}
+	_, err = integrationsFromConfig(config, client)
+	require.NotNil(t, err)
+
+	// Test CoreDNS configuration
+	config.Integrations.RFC2136 = nil
+	config.Integrations.CoreDNS = &configv1.CoreDNSIntegrationConfig{
+		TargetService: &configv1.ServiceRef{Name: "traefik", Namespace: "traefik"},
+	}
+	integrations, err = integrationsFromConfig(config, client)
+	require.Nil(t, err)
+	assert.Len(t, integrations, 3)
+	assert.Equal(t, "coredns", integrations[2].Name())
+
+	config.Integrations.CoreDNS = &configv1.CoreDNSIntegrationConfig{}
+	_, err = integrationsFromConfig(config, client)
+	require.NotNil(t, err)
+
+	// Test redirect configuration
+	config.Integrations.CoreDNS = nil
+	config.Integrations.Redirect = &configv1.RedirectIntegrationConfig{
+		Middleware: configv1.MiddlewareRef{Name: "redirect-https"},
+	}
+	integrations, err = integrationsFromConfig(config, client)
+	require.Nil(t, err)
+	assert.Len(t, integrations, 3)
+	assert.Equal(t, "redirect", integrations[2].Name())
+
+	config.Integrations.Redirect = &configv1.RedirectIntegrationConfig{EntryPoint: "web"}
+	_, err = integrationsFromConfig(config, client)
+	require.NotNil(t, err)
+
+	// Test TLS store configuration
+	config.Integrations.Redirect = nil
+	config.Integrations.TLSStore = &configv1.TLSStoreIntegrationConfig{
+		Namespace: "traefik", Mode: "certificate",
+	}
+	integrations, err = integrationsFromConfig(config, client)
+	require.Nil(t, err)
+	assert.Len(t, integrations, 3)
+	assert.Equal(t, "tls-store", integrations[2].Name())
+
+	config.Integrations.TLSStore = &configv1.TLSStoreIntegrationConfig{
+		Namespace: "traefik", Mode: "unknown",
+	}
+	_, err = integrationsFromConfig(config, client)
+	require.NotNil(t, err)
+
+	config.Integrations.TLSStore = &configv1.TLSStoreIntegrationConfig{Mode: "certificate"}
+	_, err = integrationsFromConfig(config, client)
+	require.NotNil(t, err)
+
+	// Test probe configuration
+	config.Integrations.TLSStore = nil
+	config.Integrations.Probe = &configv1.ProbeIntegrationConfig{
+		ProberURL: "blackbox-exporter:9115",
+	}
+	integrations, err = integrationsFromConfig(config, client)
+	require.Nil(t, err)
+	assert.Len(t, integrations, 3)
+	assert.Equal(t, "probe", integrations[2].Name())
+
+	config.Integrations.Probe = &configv1.ProbeIntegrationConfig{}
+	_, err = integrationsFromConfig(config, client)
+	require.NotNil(t, err)
+
+	// Test reflector configuration
+	config.Integrations.Probe = nil
+	config.Integrations.Reflector = &configv1.ReflectorIntegrationConfig{
+		AllowedNamespaces: []string{"tenant-*"},
+	}
+	integrations, err = integrationsFromConfig(config, client)
+	require.Nil(t, err)
+	assert.Len(t, integrations, 3)
+	assert.Equal(t, "reflector", integrations[2].Name())
+
+	config.Integrations.Reflector = &configv1.ReflectorIntegrationConfig{
+		AllowedNamespaces: []string{"tenant-["},
 	}
+	_, err = integrationsFromConfig(config, client)
+	require.NotNil(t, err)
+
+	// Test template configuration
+	config.Integrations.Reflector = nil
+	config.Integrations.Templates = []configv1.TemplateIntegrationConfig{{
+		Name:       "hosts",
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Template:   "apiVersion: v1\nkind: ConfigMap",
+	}}
+	integrations, err = integrationsFromConfig(config, client)
+	require.Nil(t, err)
+	assert.Len(t, integrations, 3)
+	assert.Equal(t, "hosts", integrations[2].Name())
+
+	config.Integrations.Templates[0].Name = "external-dns"
+	_, err = integrationsFromConfig(config, client)
+	require.NotNil(t, err)
+
+	config.Integrations.Templates[0].Name = "hosts"
+	config.Integrations.Templates[0].Kind = ""
+	_, err = integrationsFromConfig(config, client)
+	require.NotNil(t, err)
+
+	// Test webhook configuration
+	config.Integrations.Templates = nil
+	config.Integrations.Webhook = &configv1.WebhookIntegrationConfig{
+		URL:   "https://example.com/hook",
+		State: configv1.ConfigMapRef{Namespace: "switchboard"},
+	}
+	integrations, err = integrationsFromConfig(config, client)
+	require.Nil(t, err)
+	assert.Len(t, integrations, 3)
+	assert.Equal(t, "webhook", integrations[2].Name())
+
+	config.Integrations.Webhook.SecretEnv = "SWITCHBOARD_TEST_UNSET_WEBHOOK_SECRET"
+	_, err = integrationsFromConfig(config, client)
+	require.NotNil(t, err)
+
+	config.Integrations.Webhook.SecretEnv = ""
+	config.Integrations.Webhook.State.Namespace = ""
+	_, err = integrationsFromConfig(config, client)
+	require.NotNil(t, err)
+
+	// Test dependencies between integrations
+	config.Integrations.Webhook = nil
+	config.Integrations.CertManager.DependsOn = []string{"external-dns"}
 	integrations, err = integrationsFromConfig(config, client)
 	require.Nil(t, err)
 	assert.Len(t, integrations, 2)
 	assert.Equal(t, "external-dns", integrations[0].Name())
+	assert.Equal(t, "cert-manager", integrations[1].Name())
+
+	config.Integrations.CertManager.DependsOn = []string{"rfc2136"}
+	_, err = integrationsFromConfig(config, client)
+	require.NotNil(t, err)
+}
+
+func TestClientOptions(t *testing.T) {
+	options := ClientOptions()
+	require.NotNil(t, options.Cache)
+	assert.Contains(t, options.Cache.DisableFor, &v1.ConfigMap{})
+	assert.Contains(t, options.Cache.DisableFor, &v1.Secret{})
+}
+
+func TestIssuerKey(t *testing.T) {
+	var config configv1.CertManagerIntegrationConfig
+	config.Template.Spec.IssuerRef.Name = "letsencrypt"
+	assert.Equal(t, "Issuer/letsencrypt", issuerKey(&config))
+
+	config.Template.Spec.IssuerRef.Kind = "ClusterIssuer"
+	assert.Equal(t, "ClusterIssuer/letsencrypt", issuerKey(&config))
 }
@@ -6,35 +6,59 @@ import (
 
 	"dario.cat/mergo"
 	"github.com/borchero/switchboard/internal/k8s"
+	"github.com/borchero/switchboard/internal/switchboard"
 	certmanager "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
 type certManager struct {
-	client   client.Client
-	template certmanager.Certificate
+	client       client.Client
+	template     certmanager.Certificate
+	dependencies []string
+}
+
+const certManagerName = "cert-manager"
+
+// CertManagerOption allows to customize the cert-manager integration.
+type CertManagerOption func(*certManager)
+
+// WithCertManagerDependencies sets the integrations which must be ready before certificates are
+// created, e.g. the external-dns integration to ensure that HTTP-01 challenges can be solved.
+func WithCertManagerDependencies(names ...string) CertManagerOption {
+	return func(c *certManager) {
+		c.dependencies = names
+	}
 }
 
 // NewCertManager initializes a new cert-manager integration which creates certificates which use
 // the provided issuer.
-func NewCertManager(client client.Client, template certmanager.Certificate) Integration {
-	return &certManager{client, template}
+func NewCertManager(
+	client client.Client, template certmanager.Certificate, options ...CertManagerOption,
+) Integration {
+	integration := &certManager{client: client, template: template}
+	for _, option := range options {
+		option(integration)
+	}
+	return integration
 }
 
 func (*certManager) Name() string {
-	return "cert-manager"
+	return certManagerName
 }
 
 func (*certManager) OwnedResource() client.Object {
 	return &certmanager.Certificate{}
 }
 
-func (*certManager) WatchedObject() client.Object {
+func (*certManager) Watches() []k8s.Watch {
 	return nil
 }
 
+func (c *certManager) Dependencies() []string {
+	return c.dependencies
+}
+
 func (c *certManager) UpdateResource(
 	ctx context.Context, owner metav1.Object, info IngressInfo,
 ) error {
@@ -42,7 +66,7 @@ func (c *certManager) UpdateResource(
 	// needs to be created.
 	if info.TLSSecretName == nil || len(info.Hosts) == 0 {
 		certificate := certmanager.Certificate{ObjectMeta: c.objectMeta(owner)}
-		if err := k8s.DeleteIfFound(ctx, c.client, &certificate); err != nil {
+		if err := deleteIfFound(ctx, c.client, &certificate); err != nil {
 			return fmt.Errorf("failed to delete TLS certificate: %w", err)
 		}
 		return nil
@@ -50,7 +74,7 @@ func (c *certManager) UpdateResource(
 
 	// Otherwise, we can create the certificate resource
 	resource := certmanager.Certificate{ObjectMeta: c.objectMeta(owner)}
-	if _, err := controllerutil.CreateOrPatch(ctx, c.client, &resource, func() error {
+	if _, err := createOrPatch(ctx, c.client, &resource, func() error {
 		// Meta
 		if err := reconcileMetadata(
 			owner, &resource, c.client.Scheme(), &c.template.ObjectMeta,
@@ -76,9 +100,22 @@ func (c *certManager) UpdateResource(
 // UTILS
 //-------------------------------------------------------------------------------------------------
 
+// issuesCertificate returns whether the cert-manager integration (if enabled) creates a certificate
+// for the given owner, i.e. whether the owner's TLS secret is managed by cert-manager.
+func issuesCertificate(owner metav1.Object, info IngressInfo) bool {
+	return info.TLSSecretName != nil && *info.TLSSecretName != "" && len(info.Hosts) > 0 &&
+		switchboard.NewSelector(nil).MatchesIntegration(owner.GetAnnotations(), certManagerName)
+}
+
 func (*certManager) objectMeta(parent metav1.Object) metav1.ObjectMeta {
 	return metav1.ObjectMeta{
-		Name:      fmt.Sprintf("%s-tls", parent.GetName()),
+		Name:      certificateName(parent),
 		Namespace: parent.GetNamespace(),
 	}
 }
+
+// certificateName returns the name of the certificate that the cert-manager integration creates
+// for the given ingress.
+func certificateName(parent metav1.Object) string {
+	return fmt.Sprintf("%s-tls", parent.GetName())
+}
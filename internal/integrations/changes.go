@@ -0,0 +1,280 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// OperationResultDeleted is the operation result of a change which deleted a resource.
+const OperationResultDeleted controllerutil.OperationResult = "deleted"
+
+const redactedValue = "REDACTED"
+
+// Change describes a resource that was created, updated or deleted by an integration. Resources
+// which are managed by the integration but did not need to be modified are reported as well.
+type Change struct {
+	// Operation is the kind of change, i.e. one of `unchanged`, `created`, `updated` and
+	// `deleted`.
+	Operation controllerutil.OperationResult
+	// Object is the resource that was changed.
+	Object client.Object
+	// Summary is a human-readable description of the change.
+	Summary string
+	// Diff is the JSON merge patch from the live to the desired resource for updates and the full
+	// resource for creations. It is empty for all other operations. The values of secrets are
+	// redacted such that only the names of their keys are retained.
+	Diff string
+}
+
+// ChangeHandler is called for every change that is made by an integration.
+type ChangeHandler func(ctx context.Context, change Change)
+
+type changeHandlerKey struct{}
+
+type dryRunKey struct{}
+
+// WithChangeHandler returns a context which causes integrations to call the given handler for
+// every resource that they create, update or delete when the context is passed to
+// `UpdateResource`. Handlers that were added to the context previously are called as well.
+func WithChangeHandler(ctx context.Context, handler ChangeHandler) context.Context {
+	if previous, ok := ctx.Value(changeHandlerKey{}).(ChangeHandler); ok {
+		next := handler
+		handler = func(ctx context.Context, change Change) {
+			previous(ctx, change)
+			next(ctx, change)
+		}
+	}
+	return context.WithValue(ctx, changeHandlerKey{}, handler)
+}
+
+func notifyChange(ctx context.Context, change Change) {
+	if handler, ok := ctx.Value(changeHandlerKey{}).(ChangeHandler); ok {
+		handler(ctx, change)
+	}
+}
+
+// WithDryRun returns a context which causes integrations to skip all updates of systems other
+// than Kubernetes (e.g. DNS servers) when the context is passed to `UpdateResource`. Writes to
+// Kubernetes must be prevented by passing a dry-run client to the integrations.
+func WithDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, true)
+}
+
+func isDryRun(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunKey{}).(bool)
+	return dryRun
+}
+
+//-------------------------------------------------------------------------------------------------
+// OPERATIONS
+//-------------------------------------------------------------------------------------------------
+
+// createOrPatch wraps `controllerutil.CreateOrPatch` and notifies about the change (if any).
+func createOrPatch(
+	ctx context.Context, c client.Client, obj client.Object, f controllerutil.MutateFn,
+) (controllerutil.OperationResult, error) {
+	var before client.Object
+	result, err := controllerutil.CreateOrPatch(ctx, c, obj, func() error {
+		before = obj.DeepCopyObject().(client.Object)
+		return f()
+	})
+	if err != nil {
+		return result, err
+	}
+	switch result {
+	case controllerutil.OperationResultNone:
+		notifyUnchanged(ctx, obj)
+	case controllerutil.OperationResultCreated:
+		notifyChange(ctx, Change{
+			Operation: result, Object: obj, Summary: "created", Diff: creationDiff(obj),
+		})
+	default:
+		notifyChange(ctx, Change{
+			Operation: controllerutil.OperationResultUpdated,
+			Object:    obj,
+			Summary:   changeSummary(before, obj),
+			Diff:      updateDiff(before, obj),
+		})
+	}
+	return result, nil
+}
+
+// createObject creates the given resource and notifies about the change.
+func createObject(ctx context.Context, c client.Client, obj client.Object) error {
+	if err := c.Create(ctx, obj); err != nil {
+		return err
+	}
+	notifyChange(ctx, Change{
+		Operation: controllerutil.OperationResultCreated,
+		Object:    obj,
+		Summary:   "created",
+		Diff:      creationDiff(obj),
+	})
+	return nil
+}
+
+// updateObject updates the given resource and notifies about the change. The resource before
+// the modification is used to summarize the change.
+func updateObject(ctx context.Context, c client.Client, before, obj client.Object) error {
+	if err := c.Update(ctx, obj); err != nil {
+		return err
+	}
+	notifyChange(ctx, Change{
+		Operation: controllerutil.OperationResultUpdated,
+		Object:    obj,
+		Summary:   changeSummary(before, obj),
+		Diff:      updateDiff(before, obj),
+	})
+	return nil
+}
+
+// notifyUnchanged notifies about a managed resource which did not need to be modified.
+func notifyUnchanged(ctx context.Context, obj client.Object) {
+	notifyChange(ctx, Change{
+		Operation: controllerutil.OperationResultNone, Object: obj, Summary: "unchanged",
+	})
+}
+
+// deleteIfFound deletes the given resource if it exists and notifies about the change.
+func deleteIfFound(ctx context.Context, c client.Client, obj client.Object) error {
+	if err := c.Delete(ctx, obj); err != nil {
+		if apierrs.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to delete existing resource: %w", err)
+	}
+	notifyChange(ctx, Change{Operation: OperationResultDeleted, Object: obj, Summary: "deleted"})
+	return nil
+}
+
+//-------------------------------------------------------------------------------------------------
+// UTILS
+//-------------------------------------------------------------------------------------------------
+
+// changeSummary describes which fields differ between the two versions of a resource.
+func changeSummary(before, after client.Object) string {
+	fields := changedFields(before, after)
+	if len(fields) == 0 {
+		return "updated"
+	}
+	return "changed " + strings.Join(fields, ", ")
+}
+
+// creationDiff serializes the created resource.
+func creationDiff(obj client.Object) string {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return ""
+	}
+	return redactSecret(obj, data)
+}
+
+// updateDiff computes the JSON merge patch between the two versions of a resource.
+func updateDiff(before, after client.Object) string {
+	data, err := client.MergeFrom(before).Data(after)
+	if err != nil {
+		return ""
+	}
+	return redactSecret(after, data)
+}
+
+// redactSecret replaces all values in the (partial) JSON representation of the given resource with
+// a placeholder if the resource is a secret. Removed keys (i.e. `null` values) are retained.
+func redactSecret(obj client.Object, data []byte) string {
+	if !isSecret(obj) {
+		return string(data)
+	}
+	var content map[string]any
+	if err := json.Unmarshal(data, &content); err != nil {
+		// Never expose secret data, even if it cannot be parsed
+		return ""
+	}
+	for _, field := range []string{"data", "stringData"} {
+		values, ok := content[field].(map[string]any)
+		if !ok {
+			delete(content, field)
+			continue
+		}
+		for key, value := range values {
+			if value != nil {
+				values[key] = redactedValue
+			}
+		}
+	}
+	redacted, err := json.Marshal(content)
+	if err != nil {
+		return ""
+	}
+	return string(redacted)
+}
+
+func isSecret(obj client.Object) bool {
+	if _, ok := obj.(*v1.Secret); ok {
+		return true
+	}
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	return gvk.Group == "" && gvk.Kind == "Secret"
+}
+
+func changedFields(before, after client.Object) []string {
+	beforeMap, err := toUnstructured(before)
+	if err != nil {
+		return nil
+	}
+	afterMap, err := toUnstructured(after)
+	if err != nil {
+		return nil
+	}
+
+	var fields []string
+	for _, key := range unionKeys(beforeMap, afterMap) {
+		switch key {
+		case "apiVersion", "kind":
+			continue
+		case "metadata":
+			beforeMeta, _ := beforeMap[key].(map[string]any)
+			afterMeta, _ := afterMap[key].(map[string]any)
+			for _, field := range []string{"labels", "annotations", "ownerReferences"} {
+				if !reflect.DeepEqual(beforeMeta[field], afterMeta[field]) {
+					fields = append(fields, "metadata."+field)
+				}
+			}
+		default:
+			if !reflect.DeepEqual(beforeMap[key], afterMap[key]) {
+				fields = append(fields, key)
+			}
+		}
+	}
+	return fields
+}
+
+func toUnstructured(obj client.Object) (map[string]any, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return u.Object, nil
+	}
+	return runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+}
+
+func unionKeys(maps ...map[string]any) []string {
+	var keys []string
+	for _, m := range maps {
+		for key := range m {
+			if !slices.Contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
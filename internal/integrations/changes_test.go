@@ -0,0 +1,90 @@
+package integrations
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+func TestNotifyChange(t *testing.T) {
+	configMap := &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "my-config"}}
+	change := Change{
+		Operation: controllerutil.OperationResultCreated, Object: configMap, Summary: "created",
+	}
+
+	// Nothing should happen without a handler
+	notifyChange(context.Background(), change)
+
+	// The handler should be called otherwise
+	var changes []Change
+	ctx := WithChangeHandler(context.Background(), func(_ context.Context, change Change) {
+		changes = append(changes, change)
+	})
+	notifyChange(ctx, change)
+	assert.Equal(t, []Change{change}, changes)
+
+	// Multiple handlers should all be called
+	var summaries []string
+	ctx = WithChangeHandler(ctx, func(_ context.Context, change Change) {
+		summaries = append(summaries, change.Summary)
+	})
+	notifyChange(ctx, change)
+	assert.Len(t, changes, 2)
+	assert.Equal(t, []string{"created"}, summaries)
+}
+
+func TestChangeSummary(t *testing.T) {
+	before := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-config", Labels: map[string]string{"a": "b"}},
+		Data:       map[string]string{"key": "value"},
+	}
+
+	// Unchanged resources
+	assert.Equal(t, "updated", changeSummary(before, before.DeepCopy()))
+
+	// Changed data
+	after := before.DeepCopy()
+	after.Data["key"] = "other"
+	assert.Equal(t, "changed data", changeSummary(before, after))
+
+	// Changed metadata
+	after.Labels = nil
+	after.ResourceVersion = "2"
+	assert.Equal(t, "changed data, metadata.labels", changeSummary(before, after))
+}
+
+func TestUpdateDiff(t *testing.T) {
+	before := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-config"},
+		Data:       map[string]string{"key": "value", "other": "value"},
+	}
+	after := before.DeepCopy()
+	after.Data["key"] = "changed"
+	delete(after.Data, "other")
+	assert.JSONEq(t, `{"data":{"key":"changed","other":null}}`, updateDiff(before, after))
+}
+
+func TestSecretDiffs(t *testing.T) {
+	before := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-secret"},
+		Data:       map[string][]byte{"tls.crt": []byte("crt"), "tls.key": []byte("key")},
+	}
+	after := before.DeepCopy()
+	after.Data = map[string][]byte{"tls.key": []byte("renewed")}
+
+	// Values must be redacted while keys are retained
+	assert.JSONEq(t,
+		`{"data":{"tls.crt":null,"tls.key":"REDACTED"}}`, updateDiff(before, after),
+	)
+	assert.NotContains(t, creationDiff(before), "a2V5")
+	assert.Contains(t, creationDiff(before), `"tls.key":"REDACTED"`)
+}
+
+func TestDryRun(t *testing.T) {
+	assert.False(t, isDryRun(context.Background()))
+	assert.True(t, isDryRun(WithDryRun(context.Background())))
+}
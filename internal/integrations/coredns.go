@@ -0,0 +1,196 @@
+package integrations
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/borchero/switchboard/internal/k8s"
+	"github.com/borchero/switchboard/internal/switchboard"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	coreDNSServerKey   = "switchboard.server"
+	coreDNSHostsSuffix = ".hosts"
+	coreDNSDefaultTTL  = 300
+)
+
+type coreDNS struct {
+	client    client.Client
+	target    switchboard.Target
+	configMap types.NamespacedName
+	ttl       int64
+}
+
+// NewCoreDNS initializes a new integration which maintains a CoreDNS server block in the given
+// config map, resolving all hosts to the provided (cluster-internal) target. The server block is
+// authoritative for all hosts, answers them via the `hosts` plugin and forwards all other queries
+// to the upstream resolvers. It is stored with key `switchboard.server` such that it is imported
+// by CoreDNS deployments that import `*.server` files from a custom config map (e.g. the
+// `coredns-custom` config map). A dedicated server block is required as the default server block
+// may already contain a `hosts` plugin (e.g. for node hosts) and CoreDNS only permits one. If ttl
+// is nil, a default TTL of 300 seconds is used.
+//
+// As the config map is shared by all ingress routes, the entries of each route are additionally
+// stored in a dedicated key from which the snippet is assembled. Wildcard hosts are not supported
+// by the `hosts` plugin and are ignored.
+func NewCoreDNS(
+	client client.Client, target switchboard.Target, configMap types.NamespacedName, ttl *int64,
+) Integration {
+	ttlValue := int64(coreDNSDefaultTTL)
+	if ttl != nil {
+		ttlValue = *ttl
+	}
+	return &coreDNS{client, target, configMap, ttlValue}
+}
+
+func (*coreDNS) Name() string {
+	return "coredns"
+}
+
+func (*coreDNS) OwnedResource() client.Object {
+	return nil
+}
+
+func (c *coreDNS) Watches() []k8s.Watch {
+	return c.target.Watches()
+}
+
+func (c *coreDNS) ResolveTargets(ctx context.Context) ([]string, error) {
+	return c.target.Targets(ctx, c.client)
+}
+
+func (c *coreDNS) UpdateResource(
+	ctx context.Context, owner metav1.Object, info IngressInfo,
+) error {
+	// First, we compute the host entries for the ingress
+	hosts := slices.DeleteFunc(slices.Clone(info.Hosts), func(host string) bool {
+		return strings.HasPrefix(host, "*")
+	})
+	entries := ""
+	if len(hosts) > 0 {
+		targets, err := c.target.Targets(ctx, c.client)
+		if err != nil {
+			return fmt.Errorf("failed to query targets: %w", err)
+		}
+		entries = c.hostsEntries(hosts, targets)
+	}
+
+	// Then, we update the shared config map, retrying if it was modified concurrently
+	key := c.hostsKey(owner)
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap := v1.ConfigMap{}
+		exists := true
+		if err := c.client.Get(ctx, c.configMap, &configMap); err != nil {
+			if !apierrs.IsNotFound(err) {
+				return err
+			}
+			// There is no need to create the config map if there is nothing to add
+			if entries == "" {
+				return nil
+			}
+			exists = false
+			configMap.ObjectMeta = metav1.ObjectMeta{
+				Name:      c.configMap.Name,
+				Namespace: c.configMap.Namespace,
+				Labels:    map[string]string{managedByLabelKey: "switchboard"},
+			}
+		}
+
+		data := maps.Clone(defaultEmpty(configMap.Data))
+		if entries == "" {
+			delete(data, key)
+		} else {
+			data[key] = entries
+		}
+		if server := c.serverBlock(data); server == "" {
+			delete(data, coreDNSServerKey)
+		} else {
+			data[coreDNSServerKey] = server
+		}
+		if exists && maps.Equal(data, configMap.Data) {
+			if entries != "" {
+				notifyUnchanged(ctx, &configMap)
+			}
+			return nil
+		}
+		original := configMap.DeepCopy()
+		configMap.Data = data
+
+		if !exists {
+			return createObject(ctx, c.client, &configMap)
+		}
+		return updateObject(ctx, c.client, original, &configMap)
+	}); err != nil {
+		return fmt.Errorf("failed to update CoreDNS config map: %w", err)
+	}
+	return nil
+}
+
+//-------------------------------------------------------------------------------------------------
+// UTILS
+//-------------------------------------------------------------------------------------------------
+
+func (*coreDNS) hostsKey(owner metav1.Object) string {
+	// Namespaces cannot contain underscores such that the key is unique
+	return fmt.Sprintf("%s_%s%s", owner.GetNamespace(), owner.GetName(), coreDNSHostsSuffix)
+}
+
+func (*coreDNS) hostsEntries(hosts []string, targets []string) string {
+	lines := make([]string, 0, len(hosts)*len(targets))
+	for _, host := range hosts {
+		for _, target := range targets {
+			// The `hosts` plugin only supports IP addresses
+			if _, err := netip.ParseAddr(target); err != nil {
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("%s %s\n", target, host))
+		}
+	}
+	slices.Sort(lines)
+	return strings.Join(slices.Compact(lines), "")
+}
+
+func (c *coreDNS) serverBlock(data map[string]string) string {
+	var zones, entries []string
+	for _, key := range slices.Sorted(maps.Keys(data)) {
+		if !strings.HasSuffix(key, coreDNSHostsSuffix) {
+			continue
+		}
+		for line := range strings.Lines(data[key]) {
+			fields := strings.Fields(line)
+			if len(fields) != 2 {
+				continue
+			}
+			zones = append(zones, fields[1]+":53")
+			entries = append(entries, line)
+		}
+	}
+	if len(entries) == 0 {
+		return ""
+	}
+	slices.Sort(zones)
+	slices.Sort(entries)
+
+	var builder strings.Builder
+	builder.WriteString(strings.Join(slices.Compact(zones), " ") + " {\n")
+	builder.WriteString("    hosts {\n")
+	for _, entry := range slices.Compact(entries) {
+		builder.WriteString("        " + entry)
+	}
+	fmt.Fprintf(&builder, "        ttl %d\n", c.ttl)
+	builder.WriteString("        fallthrough\n")
+	builder.WriteString("    }\n")
+	builder.WriteString("    forward . /etc/resolv.conf\n")
+	builder.WriteString("}\n")
+	return builder.String()
+}
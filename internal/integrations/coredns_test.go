@@ -0,0 +1,169 @@
+package integrations
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/borchero/switchboard/internal/k8tests"
+	"github.com/borchero/switchboard/internal/switchboard"
+	"github.com/coredns/caddy/caddyfile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestCoreDNSUpdateResource(t *testing.T) {
+	// Setup
+	ctx := context.Background()
+	scheme := k8tests.NewScheme()
+	client := k8tests.NewClient(t, scheme)
+	namespace, shutdown := k8tests.NewNamespace(ctx, t, client)
+	defer shutdown()
+
+	owner := k8tests.DummyService("my-service", namespace, 80)
+	err := client.Create(ctx, &owner)
+	require.Nil(t, err)
+	configMapName := types.NamespacedName{Name: "coredns-custom", Namespace: namespace}
+	integration := NewCoreDNS(
+		client,
+		switchboard.NewClusterIPServicesTarget(switchboard.ServiceSelector{
+			Name: owner.Name, Namespace: namespace,
+		}),
+		configMapName,
+		nil,
+	)
+
+	// No config map should be created if no hosts are provided
+	var info IngressInfo
+	err = integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	var configMap v1.ConfigMap
+	err = client.Get(ctx, configMapName, &configMap)
+	assert.True(t, apierrs.IsNotFound(err))
+
+	// The config map should be created with entries for all hosts
+	info.Hosts = []string{"example.com", "*.example.com"}
+	err = integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	err = client.Get(ctx, configMapName, &configMap)
+	require.Nil(t, err)
+	assert.Contains(t, configMap.Data[coreDNSServerKey], owner.Spec.ClusterIP+" example.com\n")
+	assert.NotContains(t, configMap.Data[coreDNSServerKey], "*.example.com")
+
+	// When no hosts are set, the entries should be removed
+	info.Hosts = nil
+	err = integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	err = client.Get(ctx, configMapName, &configMap)
+	require.Nil(t, err)
+	assert.Empty(t, configMap.Data)
+}
+
+func TestCoreDNSHostsEntries(t *testing.T) {
+	integration := coreDNS{}
+	entries := integration.hostsEntries(
+		[]string{"www.example.com", "example.com"},
+		[]string{"10.0.0.5", "2001:db8::1", "example.lb.identifier.amazonaws.com"},
+	)
+	assert.Equal(t, "10.0.0.5 example.com\n"+
+		"10.0.0.5 www.example.com\n"+
+		"2001:db8::1 example.com\n"+
+		"2001:db8::1 www.example.com\n", entries)
+}
+
+func TestCoreDNSServerBlock(t *testing.T) {
+	integration := coreDNS{ttl: 30}
+	server := integration.serverBlock(map[string]string{
+		"b_route.hosts":  "10.0.0.5 b.example.com\n10.0.0.6 b.example.com\n",
+		"a_route.hosts":  "10.0.0.5 a.example.com\n",
+		coreDNSServerKey: "outdated",
+		"other.server":   "example.org {}",
+	})
+	assert.Equal(t, "a.example.com:53 b.example.com:53 {\n"+
+		"    hosts {\n"+
+		"        10.0.0.5 a.example.com\n"+
+		"        10.0.0.5 b.example.com\n"+
+		"        10.0.0.6 b.example.com\n"+
+		"        ttl 30\n"+
+		"        fallthrough\n"+
+		"    }\n"+
+		"    forward . /etc/resolv.conf\n"+
+		"}\n", server)
+
+	// Without entries, there should be no server block
+	assert.Empty(t, integration.serverBlock(map[string]string{"other.server": "example.org {}"}))
+}
+
+func TestCoreDNSServerBlockCorefile(t *testing.T) {
+	// The Corefile shipped with k3s, which imports the `coredns-custom` config map
+	corefile := `.:53 {
+    errors
+    health
+    ready
+    kubernetes cluster.local in-addr.arpa ip6.arpa {
+      pods insecure
+      fallthrough in-addr.arpa ip6.arpa
+    }
+    hosts /etc/coredns/NodeHosts {
+      ttl 60
+      reload 15s
+      fallthrough
+    }
+    prometheus :9153
+    forward . /etc/resolv.conf
+    cache 30
+    loop
+    reload
+    loadbalance
+    import custom/*.override
+}
+import custom/*.server
+`
+	dir := t.TempDir()
+	require.Nil(t, os.Mkdir(filepath.Join(dir, "custom"), 0o755))
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "Corefile"), []byte(corefile), 0o644))
+
+	// Write the config map as mounted into CoreDNS
+	integration := coreDNS{ttl: 30}
+	data := map[string]string{
+		"a_route.hosts": "10.0.0.5 example.com\n10.0.0.5 www.example.com\n",
+		"b_route.hosts": "10.0.0.5 example.com\n",
+	}
+	data[coreDNSServerKey] = integration.serverBlock(data)
+	for key, value := range data {
+		err := os.WriteFile(filepath.Join(dir, "custom", key), []byte(value), 0o644)
+		require.Nil(t, err)
+	}
+
+	// The resulting Corefile must be valid, i.e. every server block contains a single `hosts`
+	// directive and every zone is served by a single server block
+	file, err := os.Open(filepath.Join(dir, "Corefile"))
+	require.Nil(t, err)
+	defer file.Close()
+	blocks, err := caddyfile.Parse(file.Name(), file, nil)
+	require.Nil(t, err)
+	require.Len(t, blocks, 2)
+
+	var keys []string
+	for _, block := range blocks {
+		directives := 0
+		for _, token := range block.Tokens["hosts"] {
+			if token.Text == "hosts" {
+				directives++
+			}
+		}
+		assert.Equal(t, 1, directives)
+		keys = append(keys, block.Keys...)
+	}
+	assert.Equal(t, []string{".:53", "example.com:53", "www.example.com:53"}, keys)
+}
+
+func TestCoreDNSHostsKey(t *testing.T) {
+	integration := coreDNS{}
+	owner := k8tests.DummyService("my-service", "my-namespace", 80)
+	assert.Equal(t, "my-namespace_my-service.hosts", integration.hostsKey(&owner))
+}
@@ -3,32 +3,91 @@ package integrations
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
+	"sync"
 
-	"github.com/asaskevich/govalidator"
+	"github.com/borchero/switchboard/internal/ext"
 	"github.com/borchero/switchboard/internal/k8s"
 	"github.com/borchero/switchboard/internal/switchboard"
-	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	externaldnsv1alpha1 "sigs.k8s.io/external-dns/apis/v1alpha1"
 	"sigs.k8s.io/external-dns/endpoint"
 )
 
 type externalDNS struct {
-	client client.Client
-	target switchboard.Target
-	ttl    endpoint.TTL
+	client           client.Client
+	target           switchboard.Target
+	ttl              endpoint.TTL
+	caaRecords       []string
+	http3EntryPoints []string
+	alpn             []string
+	extraRecordTypes []string
+
+	// The generations of the DNS endpoints that were most recently written and have not been
+	// observed as ready yet. Reads may be served from a stale cache and must not miss them.
+	mutex   sync.Mutex
+	written map[types.NamespacedName]int64
+}
+
+// ExternalDNSOption customizes the DNS endpoints created by the external-dns integration.
+type ExternalDNSOption func(*externalDNS)
+
+// WithCAARecords causes the integration to additionally publish CAA records with the provided
+// values (e.g. `0 issue "letsencrypt.org"`) for all hosts of ingresses that request a TLS
+// certificate. For wildcard hosts, the records are published for the parent domain.
+func WithCAARecords(values ...string) ExternalDNSOption {
+	return func(e *externalDNS) {
+		e.caaRecords = values
+	}
+}
+
+// WithHTTPSRecords causes the integration to additionally publish HTTPS records (type 65) for all
+// hosts of ingresses bound to any of the provided HTTP/3-capable entry points. Ingresses that do
+// not specify any entry points are assumed to be bound to all entry points. The records advertise
+// the given ALPN protocol identifiers (defaulting to `h3` and `h2`) along with IP hints derived
+// from the targets.
+func WithHTTPSRecords(entryPoints []string, alpn ...string) ExternalDNSOption {
+	if len(alpn) == 0 {
+		alpn = []string{"h3", "h2"}
+	}
+	return func(e *externalDNS) {
+		e.http3EntryPoints = entryPoints
+		e.alpn = alpn
+	}
+}
+
+// WithExtraRecords allows ingresses to request additional DNS records of the provided types via
+// the `switchboard.borchero.com/dns-records` annotation. Additional records may only be created
+// for the hosts of the ingress and their subdomains.
+func WithExtraRecords(allowedTypes ...string) ExternalDNSOption {
+	return func(e *externalDNS) {
+		e.extraRecordTypes = ext.Map(allowedTypes, strings.ToUpper)
+	}
 }
 
 // NewExternalDNS initializes a new external-dns integration whose created DNS endpoints target the
 // provided service. If ttl is nil, a default TTL of 300 seconds is used.
-func NewExternalDNS(client client.Client, target switchboard.Target, ttl *int64) Integration {
+func NewExternalDNS(
+	client client.Client, target switchboard.Target, ttl *int64, options ...ExternalDNSOption,
+) Integration {
 	ttlValue := endpoint.TTL(300)
 	if ttl != nil {
 		ttlValue = endpoint.TTL(*ttl)
 	}
-	return &externalDNS{client, target, ttlValue}
+	integration := &externalDNS{
+		client:  client,
+		target:  target,
+		ttl:     ttlValue,
+		written: make(map[types.NamespacedName]int64),
+	}
+	for _, option := range options {
+		option(integration)
+	}
+	return integration
 }
 
 func (*externalDNS) Name() string {
@@ -39,17 +98,41 @@ func (*externalDNS) OwnedResource() client.Object {
 	return &externaldnsv1alpha1.DNSEndpoint{}
 }
 
-func (e *externalDNS) WatchedObject() client.Object {
-	name := e.target.NamespacedName()
-	if name == nil {
-		return nil
+func (e *externalDNS) Watches() []k8s.Watch {
+	return e.target.Watches()
+}
+
+func (e *externalDNS) ResolveTargets(ctx context.Context) ([]string, error) {
+	return e.target.Targets(ctx, e.client)
+}
+
+func (e *externalDNS) IsReady(
+	ctx context.Context, owner metav1.Object, info IngressInfo,
+) (bool, error) {
+	// Without hosts, there is no DNS endpoint to wait for
+	if len(info.Hosts) == 0 {
+		return true, nil
 	}
-	return &v1.Service{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      name.Name,
-			Namespace: name.Namespace,
-		},
+	var dnsEndpoint externaldnsv1alpha1.DNSEndpoint
+	key := client.ObjectKey{Name: owner.GetName(), Namespace: owner.GetNamespace()}
+	if err := e.client.Get(ctx, key, &dnsEndpoint); err != nil {
+		if apierrs.IsNotFound(err) {
+			// The DNS endpoint was either not created yet or is not in the cache yet
+			return false, nil
+		}
+		return false, err
 	}
+
+	// The DNS endpoint is ready once external-dns observed its latest generation. As the cache
+	// may not contain the latest write, the generation that was written is considered as well.
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	generation := max(dnsEndpoint.Generation, e.written[key])
+	if dnsEndpoint.Status.ObservedGeneration < generation {
+		return false, nil
+	}
+	delete(e.written, key)
+	return true, nil
 }
 
 func (e *externalDNS) UpdateResource(
@@ -59,9 +142,10 @@ func (e *externalDNS) UpdateResource(
 	// ignore any error if it was not found.
 	if len(info.Hosts) == 0 {
 		dnsEndpoint := externaldnsv1alpha1.DNSEndpoint{ObjectMeta: e.objectMeta(owner)}
-		if err := k8s.DeleteIfFound(ctx, e.client, &dnsEndpoint); err != nil {
+		if err := deleteIfFound(ctx, e.client, &dnsEndpoint); err != nil {
 			return fmt.Errorf("failed to delete DNS endpoint: %w", err)
 		}
+		e.recordWritten(&dnsEndpoint, 0)
 		return nil
 	}
 
@@ -71,27 +155,83 @@ func (e *externalDNS) UpdateResource(
 		return fmt.Errorf("failed to query IP for DNS A record: %w", err)
 	}
 
+	// Collect all endpoints
+	endpoints, err := e.allEndpoints(owner, info, targets)
+	if err != nil {
+		return err
+	}
+
 	// Create the endpoint resource
 	resource := externaldnsv1alpha1.DNSEndpoint{ObjectMeta: e.objectMeta(owner)}
-	if _, err := controllerutil.CreateOrPatch(ctx, e.client, &resource, func() error {
+	if _, err := createOrPatch(ctx, e.client, &resource, func() error {
 		// Meta
 		if err := reconcileMetadata(owner, &resource, e.client.Scheme()); err != nil {
 			return nil
 		}
 
 		// Spec
-		resource.Spec.Endpoints = e.endpoints(info.Hosts, targets)
+		resource.Spec.Endpoints = endpoints
 		return nil
 	}); err != nil {
 		return fmt.Errorf("failed to upsert DNS endpoint: %w", err)
 	}
+	e.recordWritten(&resource, resource.Generation)
 	return nil
 }
 
+func (e *externalDNS) Forget(namespace, name string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	delete(e.written, types.NamespacedName{Name: name, Namespace: namespace})
+}
+
+func (e *externalDNS) Validate(ctx context.Context, owner metav1.Object, info IngressInfo) error {
+	if len(info.Hosts) == 0 {
+		return nil
+	}
+	// Failing to resolve targets is no problem of the owner, conflicts are only checked partially
+	targets, err := e.target.Targets(ctx, e.client)
+	if err != nil {
+		targets = nil
+	}
+	_, err = e.allEndpoints(owner, info, targets)
+	return err
+}
+
 //-------------------------------------------------------------------------------------------------
 // UTILS
 //-------------------------------------------------------------------------------------------------
 
+func (e *externalDNS) recordWritten(
+	dnsEndpoint *externaldnsv1alpha1.DNSEndpoint, generation int64,
+) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	key := client.ObjectKeyFromObject(dnsEndpoint)
+	if generation == 0 {
+		delete(e.written, key)
+	} else {
+		e.written[key] = generation
+	}
+}
+
+func (e *externalDNS) allEndpoints(
+	owner metav1.Object, info IngressInfo, targets []string,
+) ([]*endpoint.Endpoint, error) {
+	endpoints := e.endpoints(info.Hosts, targets)
+	if issuesCertificate(owner, info) {
+		endpoints = append(endpoints, e.caaEndpoints(info.Hosts, targets)...)
+	}
+	if e.isHTTP3Capable(info.EntryPoints) {
+		endpoints = append(endpoints, e.httpsEndpoints(info.Hosts, targets)...)
+	}
+	extraEndpoints, err := e.extraEndpoints(owner.GetAnnotations(), info.Hosts, endpoints)
+	if err != nil {
+		return nil, fmt.Errorf("invalid additional DNS records: %w", err)
+	}
+	return append(endpoints, extraEndpoints...), nil
+}
+
 func (*externalDNS) objectMeta(owner metav1.Object) metav1.ObjectMeta {
 	return metav1.ObjectMeta{
 		Name:        owner.GetName(),
@@ -123,12 +263,138 @@ func (e *externalDNS) endpoints(hosts []string, targets []string) []*endpoint.En
 	return endpoints
 }
 
-func (*externalDNS) recordType(target string) string {
-	if govalidator.IsIPv4(target) {
-		return "A"
+func (e *externalDNS) caaEndpoints(hosts []string, targets []string) []*endpoint.Endpoint {
+	if len(e.caaRecords) == 0 {
+		return nil
+	}
+
+	// CAA records cannot be published for wildcard hosts, they are looked up at the parent domain.
+	// Also, hosts with a CNAME record must not have any other records.
+	cnames := make([]string, 0)
+	if slices.ContainsFunc(targets, func(target string) bool {
+		return e.recordType(target) == "CNAME"
+	}) {
+		cnames = hosts
+	}
+	names := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		name := strings.TrimPrefix(host, "*.")
+		if !slices.Contains(names, name) && !slices.Contains(cnames, name) {
+			names = append(names, name)
+		}
+	}
+
+	endpoints := make([]*endpoint.Endpoint, 0, len(names))
+	for _, name := range names {
+		endpoints = append(endpoints, &endpoint.Endpoint{
+			DNSName:    name,
+			Targets:    e.caaRecords,
+			RecordType: "CAA",
+			RecordTTL:  e.ttl,
+		})
+	}
+	return endpoints
+}
+
+func (e *externalDNS) isHTTP3Capable(entryPoints []string) bool {
+	if len(e.http3EntryPoints) == 0 {
+		return false
+	}
+	if len(entryPoints) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(entryPoints, func(entryPoint string) bool {
+		return slices.Contains(e.http3EntryPoints, entryPoint)
+	})
+}
+
+func (e *externalDNS) httpsEndpoints(hosts []string, targets []string) []*endpoint.Endpoint {
+	// Hosts with a CNAME record must not have any other records
+	ipv4Hints := make([]string, 0)
+	ipv6Hints := make([]string, 0)
+	for _, target := range targets {
+		switch e.recordType(target) {
+		case "A":
+			ipv4Hints = append(ipv4Hints, target)
+		case "AAAA":
+			ipv6Hints = append(ipv6Hints, target)
+		default:
+			return nil
+		}
+	}
+
+	// Build the record value in presentation format, using the target name "." to refer to the
+	// owner name itself
+	params := []string{"1", ".", fmt.Sprintf(`alpn="%s"`, strings.Join(e.alpn, ","))}
+	if len(ipv4Hints) > 0 {
+		params = append(params, fmt.Sprintf(`ipv4hint="%s"`, strings.Join(ipv4Hints, ",")))
+	}
+	if len(ipv6Hints) > 0 {
+		params = append(params, fmt.Sprintf(`ipv6hint="%s"`, strings.Join(ipv6Hints, ",")))
+	}
+	value := strings.Join(params, " ")
+
+	endpoints := make([]*endpoint.Endpoint, 0, len(hosts))
+	for _, host := range hosts {
+		endpoints = append(endpoints, &endpoint.Endpoint{
+			DNSName:    host,
+			Targets:    []string{value},
+			RecordType: "HTTPS",
+			RecordTTL:  e.ttl,
+		})
+	}
+	return endpoints
+}
+
+func (e *externalDNS) extraEndpoints(
+	annotations map[string]string, hosts []string, existing []*endpoint.Endpoint,
+) ([]*endpoint.Endpoint, error) {
+	if len(e.extraRecordTypes) == 0 {
+		return nil, nil
+	}
+	records, err := switchboard.DNSRecordsFromAnnotations(annotations)
+	if err != nil {
+		return nil, err
 	}
-	if govalidator.IsIPv6(target) {
-		return "AAAA"
+
+	existing = slices.Clone(existing)
+	endpoints := make([]*endpoint.Endpoint, 0, len(records))
+	for _, record := range records {
+		// Validate the record
+		if !slices.Contains(e.extraRecordTypes, record.Type) {
+			return nil, fmt.Errorf("record type %q of %q is not allowed", record.Type, record.Name)
+		}
+		if !slices.ContainsFunc(hosts, func(host string) bool {
+			domain := strings.TrimPrefix(host, "*.")
+			return record.Name == domain || strings.HasSuffix(record.Name, "."+domain)
+		}) {
+			return nil, fmt.Errorf("name %q is not covered by any host of the ingress", record.Name)
+		}
+		if slices.ContainsFunc(existing, func(ep *endpoint.Endpoint) bool {
+			return ep.DNSName == record.Name && ep.RecordType == record.Type
+		}) {
+			return nil, fmt.Errorf(
+				"%s record of %q conflicts with generated record", record.Type, record.Name,
+			)
+		}
+
+		// Create the endpoint
+		ttl := e.ttl
+		if record.TTL != nil {
+			ttl = endpoint.TTL(*record.TTL)
+		}
+		ep := &endpoint.Endpoint{
+			DNSName:    record.Name,
+			Targets:    record.Targets,
+			RecordType: record.Type,
+			RecordTTL:  ttl,
+		}
+		existing = append(existing, ep)
+		endpoints = append(endpoints, ep)
 	}
-	return "CNAME"
+	return endpoints, nil
+}
+
+func (*externalDNS) recordType(target string) string {
+	return targetRecordType(target)
 }
@@ -8,16 +8,25 @@ import (
 	"github.com/borchero/switchboard/internal/switchboard"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 	externaldnsv1alpha1 "sigs.k8s.io/external-dns/apis/v1alpha1"
 	"sigs.k8s.io/external-dns/endpoint"
 )
 
-func TestExternalDNSWatchedObject(t *testing.T) {
+func TestExternalDNSWatches(t *testing.T) {
 	integration := NewExternalDNS(nil, switchboard.NewServiceTarget("my-name", "my-namespace"), nil)
-	obj := integration.WatchedObject()
-	assert.Equal(t, "my-name", obj.GetName())
-	assert.Equal(t, "my-namespace", obj.GetNamespace())
+	watches := integration.Watches()
+	require.Len(t, watches, 1)
+	assert.Equal(t, "my-name", watches[0].Object.GetName())
+	assert.Equal(t, "my-namespace", watches[0].Object.GetNamespace())
+
+	integration = NewExternalDNS(nil, switchboard.NewStaticTarget("127.0.0.1"), nil)
+	assert.Len(t, integration.Watches(), 0)
 }
 
 func TestExternalDNSUpdateResource(t *testing.T) {
@@ -66,6 +75,136 @@ func TestExternalDNSUpdateResource(t *testing.T) {
 	assert.Len(t, getDNSEndpoints(ctx, t, client, namespace), 0)
 }
 
+func TestExternalDNSIsReady(t *testing.T) {
+	// Setup: the cache initially misses all writes and then lags behind the written generation,
+	// external-dns observes the given generation
+	ctx := context.Background()
+	stale := true
+	var written, cached, observed int64
+	c := fake.NewClientBuilder().
+		WithScheme(k8tests.NewScheme()).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(
+				ctx context.Context,
+				c client.WithWatch,
+				key client.ObjectKey,
+				obj client.Object,
+				opts ...client.GetOption,
+			) error {
+				if stale {
+					return apierrs.NewNotFound(schema.GroupResource{}, key.Name)
+				}
+				if err := c.Get(ctx, key, obj, opts...); err != nil {
+					return err
+				}
+				if dnsEndpoint, ok := obj.(*externaldnsv1alpha1.DNSEndpoint); ok {
+					dnsEndpoint.Generation = cached
+					dnsEndpoint.Status.ObservedGeneration = observed
+				}
+				return nil
+			},
+			Create: func(
+				ctx context.Context,
+				c client.WithWatch,
+				obj client.Object,
+				opts ...client.CreateOption,
+			) error {
+				if err := c.Create(ctx, obj, opts...); err != nil {
+					return err
+				}
+				written = 1
+				obj.SetGeneration(written)
+				return nil
+			},
+			Patch: func(
+				ctx context.Context,
+				c client.WithWatch,
+				obj client.Object,
+				patch client.Patch,
+				opts ...client.PatchOption,
+			) error {
+				// The fake client does not maintain generations
+				if err := c.Patch(ctx, obj, patch, opts...); err != nil {
+					return err
+				}
+				written++
+				obj.SetGeneration(written)
+				return nil
+			},
+		}).
+		Build()
+	integration := NewExternalDNS(c, switchboard.NewStaticTarget("127.0.0.1"), nil)
+	checker := integration.(ReadinessChecker)
+	owner := k8tests.DummyService("my-route", "default", 80)
+	info := IngressInfo{Hosts: []string{"example.com"}}
+
+	// Without hosts, there is nothing to wait for
+	ready, err := checker.IsReady(ctx, &owner, IngressInfo{})
+	require.Nil(t, err)
+	assert.True(t, ready)
+
+	// A just-created DNS endpoint must not be ready even if the cache has not seen it yet
+	err = integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	ready, err = checker.IsReady(ctx, &owner, info)
+	require.Nil(t, err)
+	assert.False(t, ready)
+
+	// Once external-dns observed the DNS endpoint, it should be ready
+	stale = false
+	cached = 1
+	ready, err = checker.IsReady(ctx, &owner, info)
+	require.Nil(t, err)
+	assert.False(t, ready)
+	observed = 1
+	ready, err = checker.IsReady(ctx, &owner, info)
+	require.Nil(t, err)
+	assert.True(t, ready)
+
+	// After bumping the generation, the DNS endpoint must not be ready while the cache still
+	// contains the previous generation
+	info.Hosts = append(info.Hosts, "www.example.com")
+	err = integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	ready, err = checker.IsReady(ctx, &owner, info)
+	require.Nil(t, err)
+	assert.False(t, ready)
+	observed = 2
+	ready, err = checker.IsReady(ctx, &owner, info)
+	require.Nil(t, err)
+	assert.True(t, ready)
+
+	// Written generations of routes that are forgotten must not be kept
+	err = integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	assert.Len(t, integration.(*externalDNS).written, 1)
+	integration.(Forgetter).Forget(owner.Namespace, owner.Name)
+	assert.Empty(t, integration.(*externalDNS).written)
+}
+
+func TestExternalDNSValidate(t *testing.T) {
+	integration := NewExternalDNS(
+		nil, switchboard.NewStaticTarget("127.0.0.1"), nil, WithExtraRecords("TXT"),
+	).(Validator)
+	info := IngressInfo{Hosts: []string{"example.com"}}
+	owner := &metav1.ObjectMeta{Annotations: map[string]string{
+		"switchboard.borchero.com/dns-records": `[{name: example.com, type: TXT, targets: [x]}]`,
+	}}
+	assert.Nil(t, integration.Validate(context.Background(), owner, info))
+
+	// Disallowed record types and conflicts with generated records should be rejected
+	owner.Annotations["switchboard.borchero.com/dns-records"] =
+		`[{name: example.com, type: CNAME, targets: [other.com]}]`
+	assert.NotNil(t, integration.Validate(context.Background(), owner, info))
+
+	integration = NewExternalDNS(
+		nil, switchboard.NewStaticTarget("127.0.0.1"), nil, WithExtraRecords("A"),
+	).(Validator)
+	owner.Annotations["switchboard.borchero.com/dns-records"] =
+		`[{name: example.com, type: A, targets: [127.0.0.2]}]`
+	assert.NotNil(t, integration.Validate(context.Background(), owner, info))
+}
+
 func TestExternalDNSEndpoints(t *testing.T) {
 	integration := externalDNS{ttl: 250}
 	hosts := []string{"example.com", "www.example.com"}
@@ -113,6 +252,174 @@ func TestExternalDNSEndpoints(t *testing.T) {
 	}
 }
 
+func TestExternalDNSCAAEndpoints(t *testing.T) {
+	integration := externalDNS{ttl: 250}
+	hosts := []string{"example.com", "www.example.com", "*.example.com"}
+	assert.Len(t, integration.caaEndpoints(hosts, []string{"127.0.0.1"}), 0)
+
+	integration = externalDNS{ttl: 250, caaRecords: []string{`0 issue "letsencrypt.org"`}}
+	endpoints := integration.caaEndpoints(hosts, []string{"127.0.0.1"})
+	assert.Len(t, endpoints, 2)
+	for _, ep := range endpoints {
+		assert.ElementsMatch(t, ep.Targets, []string{`0 issue "letsencrypt.org"`})
+		assert.Equal(t, ep.RecordTTL, endpoint.TTL(250))
+		assert.Equal(t, ep.RecordType, "CAA")
+		assert.Contains(t, []string{"example.com", "www.example.com"}, ep.DNSName)
+	}
+
+	// Wildcard hosts without parent host still yield a CAA record for the parent
+	endpoints = integration.caaEndpoints([]string{"*.example.com"}, []string{"127.0.0.1"})
+	assert.Len(t, endpoints, 1)
+	assert.Equal(t, "example.com", endpoints[0].DNSName)
+
+	// CAA records must not be created alongside CNAME records
+	endpoints = integration.caaEndpoints(hosts, []string{"example.lb.identifier.amazonaws.com"})
+	assert.Len(t, endpoints, 0)
+}
+
+func TestExternalDNSAllEndpointsCAA(t *testing.T) {
+	integration := externalDNS{ttl: 250, caaRecords: []string{`0 issue "letsencrypt.org"`}}
+	owner := k8tests.DummyService("my-service", "default", 80)
+	tlsName := "my-tls"
+	info := IngressInfo{Hosts: []string{"example.com"}, TLSSecretName: &tlsName}
+	caaCount := func(endpoints []*endpoint.Endpoint) int {
+		count := 0
+		for _, ep := range endpoints {
+			if ep.RecordType == "CAA" {
+				count++
+			}
+		}
+		return count
+	}
+
+	// CAA records should be published if cert-manager issues a certificate...
+	endpoints, err := integration.allEndpoints(&owner, info, []string{"127.0.0.1"})
+	require.Nil(t, err)
+	assert.Equal(t, 1, caaCount(endpoints))
+
+	// ...but not if the ingress ignores the cert-manager integration
+	owner.Annotations = map[string]string{"switchboard.borchero.com/ignore": "cert-manager"}
+	endpoints, err = integration.allEndpoints(&owner, info, []string{"127.0.0.1"})
+	require.Nil(t, err)
+	assert.Equal(t, 0, caaCount(endpoints))
+	assert.Len(t, endpoints, 1)
+}
+
+func TestExternalDNSWithCAARecords(t *testing.T) {
+	integration := NewExternalDNS(
+		nil, switchboard.NewStaticTarget("127.0.0.1"), nil,
+		WithCAARecords(`0 issue "letsencrypt.org"`),
+	)
+	externalDNSImpl := integration.(*externalDNS)
+	assert.Equal(t, []string{`0 issue "letsencrypt.org"`}, externalDNSImpl.caaRecords)
+}
+
+func TestExternalDNSIsHTTP3Capable(t *testing.T) {
+	integration := externalDNS{ttl: 250}
+	assert.False(t, integration.isHTTP3Capable(nil))
+	assert.False(t, integration.isHTTP3Capable([]string{"websecure"}))
+
+	WithHTTPSRecords([]string{"websecure"})(&integration)
+	assert.True(t, integration.isHTTP3Capable(nil))
+	assert.True(t, integration.isHTTP3Capable([]string{"web", "websecure"}))
+	assert.False(t, integration.isHTTP3Capable([]string{"web"}))
+}
+
+func TestExternalDNSHTTPSEndpoints(t *testing.T) {
+	integration := externalDNS{ttl: 250}
+	WithHTTPSRecords([]string{"websecure"})(&integration)
+	hosts := []string{"example.com", "www.example.com"}
+
+	endpoints := integration.httpsEndpoints(hosts, []string{"127.0.0.1", "2001:db8::1"})
+	assert.Len(t, endpoints, 2)
+	for _, ep := range endpoints {
+		assert.ElementsMatch(t, ep.Targets, []string{
+			`1 . alpn="h3,h2" ipv4hint="127.0.0.1" ipv6hint="2001:db8::1"`,
+		})
+		assert.Equal(t, ep.RecordTTL, endpoint.TTL(250))
+		assert.Equal(t, ep.RecordType, "HTTPS")
+		assert.Contains(t, hosts, ep.DNSName)
+	}
+
+	WithHTTPSRecords([]string{"websecure"}, "h3")(&integration)
+	endpoints = integration.httpsEndpoints(hosts, []string{"127.0.0.1", "127.0.0.2"})
+	assert.Len(t, endpoints, 2)
+	assert.ElementsMatch(t, endpoints[0].Targets, []string{
+		`1 . alpn="h3" ipv4hint="127.0.0.1,127.0.0.2"`,
+	})
+
+	// HTTPS records must not be created alongside CNAME records
+	endpoints = integration.httpsEndpoints(hosts, []string{"example.lb.identifier.amazonaws.com"})
+	assert.Len(t, endpoints, 0)
+}
+
+func TestExternalDNSExtraEndpoints(t *testing.T) {
+	integration := externalDNS{ttl: 250}
+	hosts := []string{"example.com", "*.example.net"}
+	existing := integration.endpoints(hosts, []string{"127.0.0.1"})
+	annotations := map[string]string{
+		"switchboard.borchero.com/dns-records": `
+- name: example.com
+  type: TXT
+  targets: ["token=abc"]
+- name: mail.example.net
+  type: MX
+  targets: ["10 mx.example.net"]
+  ttl: 3600
+`,
+	}
+
+	// Annotation is ignored if no record types are allowed
+	endpoints, err := integration.extraEndpoints(annotations, hosts, existing)
+	require.Nil(t, err)
+	assert.Len(t, endpoints, 0)
+
+	// Records are created if all types are allowed
+	WithExtraRecords("txt", "mx")(&integration)
+	endpoints, err = integration.extraEndpoints(annotations, hosts, existing)
+	require.Nil(t, err)
+	require.Len(t, endpoints, 2)
+	assert.Equal(t, "example.com", endpoints[0].DNSName)
+	assert.Equal(t, "TXT", endpoints[0].RecordType)
+	assert.Equal(t, endpoint.TTL(250), endpoints[0].RecordTTL)
+	assert.Equal(t, "mail.example.net", endpoints[1].DNSName)
+	assert.Equal(t, "MX", endpoints[1].RecordType)
+	assert.Equal(t, endpoint.TTL(3600), endpoints[1].RecordTTL)
+
+	// Records must have an allowed type
+	WithExtraRecords("TXT")(&integration)
+	_, err = integration.extraEndpoints(annotations, hosts, existing)
+	assert.NotNil(t, err)
+
+	// Records must be covered by the hosts
+	_, err = integration.extraEndpoints(map[string]string{
+		"switchboard.borchero.com/dns-records": `[{"name": "example.org", "type": "TXT", ` +
+			`"targets": ["token=abc"]}]`,
+	}, hosts, existing)
+	assert.NotNil(t, err)
+
+	_, err = integration.extraEndpoints(map[string]string{
+		"switchboard.borchero.com/dns-records": `[{"name": "myexample.com", "type": "TXT", ` +
+			`"targets": ["token=abc"]}]`,
+	}, hosts, existing)
+	assert.NotNil(t, err)
+
+	// Records must not conflict with generated records
+	WithExtraRecords("TXT", "A")(&integration)
+	_, err = integration.extraEndpoints(map[string]string{
+		"switchboard.borchero.com/dns-records": `[{"name": "example.com", "type": "A", ` +
+			`"targets": ["127.0.0.2"]}]`,
+	}, hosts, existing)
+	assert.NotNil(t, err)
+
+	_, err = integration.extraEndpoints(map[string]string{
+		"switchboard.borchero.com/dns-records": `[` +
+			`{"name": "example.com", "type": "TXT", "targets": ["token=abc"]}, ` +
+			`{"name": "example.com", "type": "TXT", "targets": ["token=def"]}]`,
+	}, hosts, existing)
+	assert.NotNil(t, err)
+}
+
 func TestExternalDNSRecordType(t *testing.T) {
 	integration := externalDNS{ttl: 250}
 	assert.Equal(t, "A", integration.recordType("127.0.0.1"))
@@ -3,6 +3,7 @@ package integrations
 import (
 	"context"
 
+	"github.com/borchero/switchboard/internal/k8s"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -10,12 +11,15 @@ import (
 const (
 	managedByLabelKey    = "kubernetes.io/managed-by"
 	ingressAnnotationKey = "kubernetes.io/ingress.class"
+	ignoreAnnotationKey  = "switchboard.borchero.com/ignore"
 )
 
 // IngressInfo encapsulates information extracted from ingress objects that integrations act upon.
 type IngressInfo struct {
 	Hosts         []string
 	TLSSecretName *string
+	TLSOptionName *string
+	EntryPoints   []string
 }
 
 // Integration is an interface for any component that allows to create "derivative" Kubernetes
@@ -26,14 +30,16 @@ type Integration interface {
 	Name() string
 
 	// OwnedResource returns the resource (i.e. CRD of an external tool) that this integration
-	// owns. The resource should be "empty", i.e. no fields should be set.
+	// owns. The resource should be "empty", i.e. no fields should be set. If the integration does
+	// not manage any Kubernetes resources, nil is returned and the integration is called with empty
+	// ingress information once the ingress is deleted.
 	OwnedResource() client.Object
 
-	// WatchedObject optionally returns a particular object whose changes require the
-	// reconciliation of all resources that this integration is applied to. In contrast to
-	// `OwnedResource`, this method returns a concrete object (i.e. its name and namespace must
-	// set set). If the integration does not watch any resources, this method may return `nil`.
-	WatchedObject() client.Object
+	// Watches optionally returns sets of objects whose changes require the reconciliation of all
+	// resources that this integration is applied to. In contrast to `OwnedResource`, the watched
+	// objects are typically narrowed down to particular objects via predicates. If the
+	// integration does not watch any resources, this method may return `nil`.
+	Watches() []k8s.Watch
 
 	// UpdateResource updates the resource that ought to be owned by the passed object. Updating
 	// may entail creating the resource, updating an existing resource, or deleting the resouce.
@@ -41,3 +47,40 @@ type Integration interface {
 	// configuration along with the given ingress information.
 	UpdateResource(ctx context.Context, owner metav1.Object, info IngressInfo) error
 }
+
+// TargetResolver is optionally implemented by integrations which point the hosts of ingresses to
+// targets (e.g. via DNS records).
+type TargetResolver interface {
+	// ResolveTargets returns the targets that the hosts of ingresses currently point to.
+	ResolveTargets(ctx context.Context) ([]string, error)
+}
+
+// Dependent is optionally implemented by integrations which must only be run for an ingress once
+// other integrations have been run and their resources are ready.
+type Dependent interface {
+	// Dependencies returns the names of the integrations that this integration depends on.
+	Dependencies() []string
+}
+
+// ReadinessChecker is optionally implemented by integrations whose resources only become effective
+// once they have been processed by an external controller.
+type ReadinessChecker interface {
+	// IsReady returns whether the resources for the given owner have been processed. The ingress
+	// information is the one that the integration was most recently run with.
+	IsReady(ctx context.Context, owner metav1.Object, info IngressInfo) (bool, error)
+}
+
+// Forgetter is optionally implemented by integrations which keep in-memory state for ingresses.
+type Forgetter interface {
+	// Forget drops all state kept for the ingress with the given namespace and name. It is called
+	// once the ingress is deleted or not processed anymore.
+	Forget(namespace, name string)
+}
+
+// Validator is optionally implemented by integrations which reject some ingresses, e.g. due to
+// allowlists in their configuration.
+type Validator interface {
+	// Validate returns an error if updating the resources for the given owner would fail due to
+	// the owner's configuration. Validation must not modify any resources.
+	Validate(ctx context.Context, owner metav1.Object, info IngressInfo) error
+}
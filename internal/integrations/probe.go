@@ -0,0 +1,111 @@
+package integrations
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/borchero/switchboard/internal/k8s"
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const probeDefaultModule = "http_2xx"
+
+type probe struct {
+	client    client.Client
+	proberURL string
+	module    string
+	labels    map[string]string
+}
+
+// NewProbe initializes a new integration which creates a prometheus-operator probe for every
+// ingress route, probing all of its hosts via the blackbox exporter reachable at the given prober
+// URL (e.g. `blackbox-exporter.monitoring.svc:9115`). Hosts are probed via HTTPS if the ingress
+// route specifies TLS. If the module is empty, it defaults to `http_2xx`. The provided labels are
+// added to all probes, e.g. to be selected by a Prometheus instance.
+func NewProbe(
+	client client.Client, proberURL string, module string, labels map[string]string,
+) Integration {
+	if module == "" {
+		module = probeDefaultModule
+	}
+	return &probe{client, proberURL, module, labels}
+}
+
+func (*probe) Name() string {
+	return "probe"
+}
+
+func (*probe) OwnedResource() client.Object {
+	return &monitoringv1.Probe{}
+}
+
+func (*probe) Watches() []k8s.Watch {
+	return nil
+}
+
+func (p *probe) UpdateResource(
+	ctx context.Context, owner metav1.Object, info IngressInfo,
+) error {
+	// If the ingress specifies no hosts that can be probed, there should be no probe
+	targets := p.targets(info)
+	if len(targets) == 0 {
+		resource := monitoringv1.Probe{ObjectMeta: p.objectMeta(owner)}
+		if err := deleteIfFound(ctx, p.client, &resource); err != nil {
+			return fmt.Errorf("failed to delete probe: %w", err)
+		}
+		return nil
+	}
+
+	// Otherwise, we can create the probe
+	resource := monitoringv1.Probe{ObjectMeta: p.objectMeta(owner)}
+	template := metav1.ObjectMeta{Labels: p.labels}
+	if _, err := createOrPatch(ctx, p.client, &resource, func() error {
+		// Meta
+		if err := reconcileMetadata(owner, &resource, p.client.Scheme(), &template); err != nil {
+			return fmt.Errorf("failed to reconcile metadata: %s", err)
+		}
+
+		// Spec
+		resource.Spec.ProberSpec.URL = p.proberURL
+		resource.Spec.Module = p.module
+		resource.Spec.Targets = monitoringv1.ProbeTargets{
+			StaticConfig: &monitoringv1.ProbeTargetStaticConfig{Targets: targets},
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to upsert probe: %w", err)
+	}
+	return nil
+}
+
+//-------------------------------------------------------------------------------------------------
+// UTILS
+//-------------------------------------------------------------------------------------------------
+
+func (*probe) objectMeta(owner metav1.Object) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      owner.GetName(),
+		Namespace: owner.GetNamespace(),
+	}
+}
+
+func (*probe) targets(info IngressInfo) []string {
+	scheme := "http"
+	if info.TLSSecretName != nil {
+		scheme = "https"
+	}
+	targets := make([]string, 0, len(info.Hosts))
+	for _, host := range info.Hosts {
+		// Wildcard hosts cannot be probed
+		if strings.HasPrefix(host, "*") {
+			continue
+		}
+		targets = append(targets, fmt.Sprintf("%s://%s", scheme, host))
+	}
+	slices.Sort(targets)
+	return targets
+}
@@ -0,0 +1,82 @@
+package integrations
+
+import (
+	"context"
+	"testing"
+
+	"github.com/borchero/switchboard/internal/k8tests"
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestProbeUpdateResource(t *testing.T) {
+	// Setup
+	ctx := context.Background()
+	scheme := k8tests.NewScheme()
+	client := k8tests.NewClient(t, scheme)
+	namespace, shutdown := k8tests.NewNamespace(ctx, t, client)
+	defer shutdown()
+
+	// Create a dummy service as owner
+	owner := k8tests.DummyService("my-service", namespace, 80)
+	err := client.Create(ctx, &owner)
+	require.Nil(t, err)
+	integration := NewProbe(
+		client, "blackbox-exporter:9115", "", map[string]string{"release": "prometheus"},
+	)
+
+	// No probe should be created if no hosts are provided
+	var info IngressInfo
+	err = integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	assert.Len(t, getProbes(ctx, t, client, namespace), 0)
+
+	// A probe should be created for the hosts
+	info.Hosts = []string{"example.com"}
+	err = integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	probes := getProbes(ctx, t, client, namespace)
+	require.Len(t, probes, 1)
+	assert.Equal(t, owner.Name, probes[0].Name)
+	assert.Equal(t, "prometheus", probes[0].Labels["release"])
+	assert.Equal(t, "blackbox-exporter:9115", probes[0].Spec.ProberSpec.URL)
+	assert.Equal(t, "http_2xx", probes[0].Spec.Module)
+	assert.Equal(
+		t, []string{"http://example.com"}, probes[0].Spec.Targets.StaticConfig.Targets,
+	)
+
+	// When no hosts are set, the probe should be removed
+	info.Hosts = nil
+	err = integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	assert.Len(t, getProbes(ctx, t, client, namespace), 0)
+}
+
+func TestProbeTargets(t *testing.T) {
+	var integration probe
+	hosts := []string{"www.example.com", "*.example.com", "example.com"}
+
+	targets := integration.targets(IngressInfo{Hosts: hosts})
+	assert.Equal(t, []string{"http://example.com", "http://www.example.com"}, targets)
+
+	tlsName := "test-tls"
+	targets = integration.targets(IngressInfo{Hosts: hosts, TLSSecretName: &tlsName})
+	assert.Equal(t, []string{"https://example.com", "https://www.example.com"}, targets)
+}
+
+//-------------------------------------------------------------------------------------------------
+// UTILS
+//-------------------------------------------------------------------------------------------------
+
+func getProbes(
+	ctx context.Context, t *testing.T, ctrlClient client.Client, namespace string,
+) []monitoringv1.Probe {
+	var list monitoringv1.ProbeList
+	err := ctrlClient.List(ctx, &list, &client.ListOptions{
+		Namespace: namespace,
+	})
+	require.Nil(t, err)
+	return list.Items
+}
@@ -0,0 +1,152 @@
+package integrations
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/borchero/switchboard/internal/k8s"
+	traefik "github.com/traefik/traefik/v3/pkg/provider/kubernetes/crd/traefikio/v1alpha1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	redirectDefaultEntryPoint = "web"
+	redirectNoopService       = "noop@internal"
+)
+
+type redirect struct {
+	client     client.Client
+	entryPoint string
+	middleware traefik.MiddlewareRef
+}
+
+// NewRedirect initializes a new integration which creates a companion ingress route for every
+// ingress route with TLS. The companion route is bound to the given (plain HTTP) entry point and
+// uses the referenced middleware (typically a `redirectScheme` middleware) for all hosts of the
+// ingress route. If the entry point is empty, it defaults to `web`. Existing ingress routes with the
+// name of the companion route that are not controlled by the ingress route are never modified.
+func NewRedirect(
+	client client.Client, entryPoint string, middleware traefik.MiddlewareRef,
+) Integration {
+	if entryPoint == "" {
+		entryPoint = redirectDefaultEntryPoint
+	}
+	return &redirect{client, entryPoint, middleware}
+}
+
+func (*redirect) Name() string {
+	return "redirect"
+}
+
+func (*redirect) OwnedResource() client.Object {
+	return &traefik.IngressRoute{}
+}
+
+func (*redirect) Watches() []k8s.Watch {
+	return nil
+}
+
+func (r *redirect) UpdateResource(
+	ctx context.Context, owner metav1.Object, info IngressInfo,
+) error {
+	// Ingress routes with the name of the companion route must never be taken over
+	owned, err := r.isOwnedRoute(ctx, owner)
+	if err != nil {
+		return err
+	}
+
+	// If the ingress does not use TLS or specifies no hosts, there is nothing to redirect
+	if info.TLSSecretName == nil || len(info.Hosts) == 0 {
+		if !owned {
+			return nil
+		}
+		route := traefik.IngressRoute{ObjectMeta: r.objectMeta(owner)}
+		if err := deleteIfFound(ctx, r.client, &route); err != nil {
+			return fmt.Errorf("failed to delete redirect ingress route: %w", err)
+		}
+		return nil
+	}
+
+	// Otherwise, we create the companion route which must not be processed by Switchboard itself
+	route := traefik.IngressRoute{ObjectMeta: r.objectMeta(owner)}
+	if !owned {
+		return fmt.Errorf(
+			"ingress route %q already exists and is not managed by switchboard", route.Name,
+		)
+	}
+	template := metav1.ObjectMeta{
+		Annotations: map[string]string{ignoreAnnotationKey: "all"},
+	}
+	if _, err := createOrPatch(ctx, r.client, &route, func() error {
+		// Meta
+		if err := reconcileMetadata(owner, &route, r.client.Scheme(), &template); err != nil {
+			return fmt.Errorf("failed to reconcile metadata: %s", err)
+		}
+
+		// Spec
+		route.Spec = traefik.IngressRouteSpec{
+			EntryPoints: []string{r.entryPoint},
+			Routes: []traefik.Route{{
+				Kind:        "Rule",
+				Match:       r.match(info.Hosts),
+				Middlewares: []traefik.MiddlewareRef{r.middleware},
+				Services: []traefik.Service{{
+					LoadBalancerSpec: traefik.LoadBalancerSpec{
+						Name: redirectNoopService,
+						Kind: "TraefikService",
+					},
+				}},
+			}},
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to upsert redirect ingress route: %w", err)
+	}
+	return nil
+}
+
+//-------------------------------------------------------------------------------------------------
+// UTILS
+//-------------------------------------------------------------------------------------------------
+
+func (*redirect) objectMeta(parent metav1.Object) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      fmt.Sprintf("%s-redirect", parent.GetName()),
+		Namespace: parent.GetNamespace(),
+	}
+}
+
+// isOwnedRoute returns whether the companion route of the owner either does not exist or is
+// controlled by the owner.
+func (r *redirect) isOwnedRoute(ctx context.Context, owner metav1.Object) (bool, error) {
+	meta := r.objectMeta(owner)
+	var route traefik.IngressRoute
+	key := types.NamespacedName{Name: meta.Name, Namespace: meta.Namespace}
+	if err := r.client.Get(ctx, key, &route); err != nil {
+		if apierrs.IsNotFound(err) {
+			return true, nil
+		}
+		return false, fmt.Errorf("failed to get redirect ingress route: %w", err)
+	}
+	return metav1.IsControlledBy(&route, owner), nil
+}
+
+func (*redirect) match(hosts []string) string {
+	rules := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		if suffix, ok := strings.CutPrefix(host, "*."); ok {
+			// Wildcards match exactly one additional label
+			pattern := strings.ReplaceAll(suffix, ".", `\.`)
+			rules = append(rules, fmt.Sprintf("HostRegexp(`^[^.]+\\.%s$`)", pattern))
+		} else {
+			rules = append(rules, fmt.Sprintf("Host(`%s`)", host))
+		}
+	}
+	slices.Sort(rules)
+	return strings.Join(rules, " || ")
+}
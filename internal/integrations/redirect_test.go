@@ -0,0 +1,128 @@
+package integrations
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/borchero/switchboard/internal/k8tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	traefik "github.com/traefik/traefik/v3/pkg/provider/kubernetes/crd/traefikio/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRedirectUpdateResource(t *testing.T) {
+	// Setup
+	ctx := context.Background()
+	scheme := k8tests.NewScheme()
+	client := k8tests.NewClient(t, scheme)
+	namespace, shutdown := k8tests.NewNamespace(ctx, t, client)
+	defer shutdown()
+
+	// Create a dummy service as owner
+	owner := k8tests.DummyService("my-service", namespace, 80)
+	err := client.Create(ctx, &owner)
+	require.Nil(t, err)
+	integration := NewRedirect(client, "", traefik.MiddlewareRef{Name: "redirect-https"})
+
+	// Nothing should be created if no TLS is set
+	info := IngressInfo{Hosts: []string{"example.com"}}
+	err = integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	assert.Len(t, getIngressRoutes(ctx, t, client, namespace), 0)
+
+	// If TLS is set, the companion route should be created
+	tlsName := "test-tls"
+	info.TLSSecretName = &tlsName
+	err = integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	routes := getIngressRoutes(ctx, t, client, namespace)
+	require.Len(t, routes, 1)
+	assert.Equal(t, fmt.Sprintf("%s-redirect", owner.Name), routes[0].Name)
+	assert.Equal(t, "all", routes[0].Annotations[ignoreAnnotationKey])
+	assert.Equal(t, []string{"web"}, routes[0].Spec.EntryPoints)
+	require.Len(t, routes[0].Spec.Routes, 1)
+	assert.Equal(t, "Host(`example.com`)", routes[0].Spec.Routes[0].Match)
+	assert.Equal(t, "redirect-https", routes[0].Spec.Routes[0].Middlewares[0].Name)
+
+	// When TLS is removed, the companion route should be removed again
+	info.TLSSecretName = nil
+	err = integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	assert.Len(t, getIngressRoutes(ctx, t, client, namespace), 0)
+}
+
+func TestRedirectExistingRoute(t *testing.T) {
+	ctx := context.Background()
+	existing := &traefik.IngressRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-service-redirect", Namespace: "default"},
+		Spec:       traefik.IngressRouteSpec{EntryPoints: []string{"websecure"}},
+	}
+	client := fake.NewClientBuilder().
+		WithScheme(k8tests.NewScheme()).
+		WithObjects(existing).
+		Build()
+	integration := NewRedirect(client, "", traefik.MiddlewareRef{Name: "redirect-https"})
+	owner := k8tests.DummyService("my-service", "default", 80)
+	owner.UID = "my-service-uid"
+	key := types.NamespacedName{Name: existing.Name, Namespace: existing.Namespace}
+
+	// Ingress routes which are not controlled by the owner must not be taken over...
+	tlsName := "test-tls"
+	info := IngressInfo{Hosts: []string{"example.com"}, TLSSecretName: &tlsName}
+	err := integration.UpdateResource(ctx, &owner, info)
+	assert.ErrorContains(t, err, "not managed by switchboard")
+	var route traefik.IngressRoute
+	err = client.Get(ctx, key, &route)
+	require.Nil(t, err)
+	assert.Equal(t, existing.Spec, route.Spec)
+	assert.Empty(t, route.OwnerReferences)
+
+	// ...nor deleted
+	info.TLSSecretName = nil
+	err = integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	err = client.Get(ctx, key, &route)
+	require.Nil(t, err)
+
+	// Companion routes controlled by the owner should still be updated
+	other := k8tests.DummyService("other", "default", 80)
+	other.UID = "other-uid"
+	info.TLSSecretName = &tlsName
+	err = integration.UpdateResource(ctx, &other, info)
+	require.Nil(t, err)
+	err = integration.UpdateResource(ctx, &other, info)
+	require.Nil(t, err)
+	err = client.Get(ctx, types.NamespacedName{Name: "other-redirect", Namespace: "default"}, &route)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"web"}, route.Spec.EntryPoints)
+}
+
+func TestRedirectMatch(t *testing.T) {
+	integration := redirect{}
+	assert.Equal(t, "Host(`example.com`)", integration.match([]string{"example.com"}))
+	assert.Equal(
+		t,
+		"Host(`example.com`) || HostRegexp(`^[^.]+\\.example\\.com$`)",
+		integration.match([]string{"*.example.com", "example.com"}),
+	)
+}
+
+//-------------------------------------------------------------------------------------------------
+// UTILS
+//-------------------------------------------------------------------------------------------------
+
+func getIngressRoutes(
+	ctx context.Context, t *testing.T, ctrlClient client.Client, namespace string,
+) []traefik.IngressRoute {
+	var list traefik.IngressRouteList
+	err := ctrlClient.List(ctx, &list, &client.ListOptions{
+		Namespace: namespace,
+	})
+	require.Nil(t, err)
+	return list.Items
+}
@@ -0,0 +1,223 @@
+package integrations
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+
+	"github.com/borchero/switchboard/internal/k8s"
+	"github.com/borchero/switchboard/internal/switchboard"
+	certmanager "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	reflectedNamespaceLabelKey = "switchboard.borchero.com/reflected-from-namespace"
+	reflectedRouteAnnotation   = "switchboard.borchero.com/reflected-from-route"
+	certificateNameAnnotation  = "cert-manager.io/certificate-name"
+)
+
+type reflector struct {
+	client            client.Client
+	allowedNamespaces []string
+}
+
+// NewReflector initializes a new integration which copies the TLS secrets issued via the
+// cert-manager integration into the namespaces listed in the
+// `switchboard.borchero.com/reflect-tls-to` annotation of an ingress. Only namespaces matching
+// one of the allowed namespace patterns (see `path.Match`) may be used. Copies are kept in sync
+// with the source secret and removed once they are no longer requested or the source secret is
+// deleted.
+func NewReflector(client client.Client, allowedNamespaces []string) Integration {
+	return &reflector{client, allowedNamespaces}
+}
+
+func (*reflector) Name() string {
+	return "reflector"
+}
+
+func (*reflector) OwnedResource() client.Object {
+	// Owner references cannot span namespaces, copies are cleaned up explicitly
+	return nil
+}
+
+func (r *reflector) Watches() []k8s.Watch {
+	// Source secrets and copies are relevant, the former to propagate renewals. Only metadata is
+	// watched to not cache the contents of all secrets in the cluster.
+	isRelevant := func(obj client.Object) bool {
+		if _, ok := obj.GetAnnotations()[certificateNameAnnotation]; ok {
+			return true
+		}
+		_, ok := obj.GetLabels()[reflectedNamespaceLabelKey]
+		return ok
+	}
+	secret := &metav1.PartialObjectMetadata{}
+	secret.SetGroupVersionKind(v1.SchemeGroupVersion.WithKind("Secret"))
+	return []k8s.Watch{{
+		Object: secret,
+		Predicates: []predicate.Predicate{predicate.Funcs{
+			CreateFunc: func(e event.CreateEvent) bool {
+				return isRelevant(e.Object)
+			},
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				return isRelevant(e.ObjectNew) &&
+					e.ObjectOld.GetResourceVersion() != e.ObjectNew.GetResourceVersion()
+			},
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return isRelevant(e.Object)
+			},
+			GenericFunc: func(event.GenericEvent) bool {
+				return false
+			},
+		}},
+		Map: r.routeOf,
+	}}
+}
+
+func (r *reflector) UpdateResource(
+	ctx context.Context, owner metav1.Object, info IngressInfo,
+) error {
+	// First, we find the namespaces that the TLS secret should be copied to...
+	if err := r.Validate(ctx, owner, info); err != nil {
+		return err
+	}
+	namespaces := switchboard.ReflectionNamespacesFromAnnotations(owner.GetAnnotations())
+
+	// ...and the source secret
+	var source *v1.Secret
+	if info.TLSSecretName != nil && len(namespaces) > 0 {
+		var secret v1.Secret
+		name := types.NamespacedName{Name: *info.TLSSecretName, Namespace: owner.GetNamespace()}
+		if err := r.client.Get(ctx, name, &secret); err != nil {
+			if !apierrs.IsNotFound(err) {
+				return fmt.Errorf("failed to query TLS secret: %w", err)
+			}
+		} else if secret.Annotations[certificateNameAnnotation] == certificateName(owner) {
+			source = &secret
+		}
+	}
+	if source == nil {
+		namespaces = nil
+	}
+
+	// Then, we upsert all copies...
+	for _, namespace := range namespaces {
+		if namespace == owner.GetNamespace() {
+			continue
+		}
+		secret := v1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:      source.Name,
+			Namespace: namespace,
+		}}
+		if _, err := createOrPatch(ctx, r.client, &secret, func() error {
+			if !secret.CreationTimestamp.IsZero() && !r.isCopyOf(&secret, owner) {
+				return fmt.Errorf("secret exists and is not managed by switchboard")
+			}
+			labels := defaultEmpty(secret.GetLabels())
+			labels[managedByLabelKey] = "switchboard"
+			labels[reflectedNamespaceLabelKey] = owner.GetNamespace()
+			secret.SetLabels(labels)
+			annotations := defaultEmpty(secret.GetAnnotations())
+			annotations[reflectedRouteAnnotation] = owner.GetName()
+			secret.SetAnnotations(annotations)
+
+			secret.Type = source.Type
+			secret.Data = source.Data
+			return nil
+		}); err != nil {
+			return fmt.Errorf("failed to upsert TLS secret in namespace %q: %w", namespace, err)
+		}
+	}
+
+	// ...and remove the ones that are not requested anymore
+	var copies v1.SecretList
+	if err := r.client.List(ctx, &copies, client.MatchingLabels{
+		reflectedNamespaceLabelKey: owner.GetNamespace(),
+	}); err != nil {
+		return fmt.Errorf("failed to list TLS secret copies: %w", err)
+	}
+	for _, secret := range copies.Items {
+		if !r.isCopyOf(&secret, owner) {
+			continue
+		}
+		if source != nil && secret.Name == source.Name &&
+			slices.Contains(namespaces, secret.Namespace) {
+			continue
+		}
+		if err := deleteIfFound(ctx, r.client, &secret); err != nil {
+			return fmt.Errorf("failed to delete TLS secret copy: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *reflector) Validate(_ context.Context, owner metav1.Object, _ IngressInfo) error {
+	namespaces := switchboard.ReflectionNamespacesFromAnnotations(owner.GetAnnotations())
+	for _, namespace := range namespaces {
+		if !r.isAllowed(namespace) {
+			return fmt.Errorf("reflecting TLS secret to namespace %q is not allowed", namespace)
+		}
+	}
+	return nil
+}
+
+//-------------------------------------------------------------------------------------------------
+// UTILS
+//-------------------------------------------------------------------------------------------------
+
+func (r *reflector) isAllowed(namespace string) bool {
+	for _, pattern := range r.allowedNamespaces {
+		if ok, err := path.Match(pattern, namespace); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *reflector) routeOf(
+	ctx context.Context, secret client.Object,
+) ([]reconcile.Request, error) {
+	// Copies reference their route directly...
+	if namespace, ok := secret.GetLabels()[reflectedNamespaceLabelKey]; ok {
+		name := secret.GetAnnotations()[reflectedRouteAnnotation]
+		if name == "" {
+			return nil, nil
+		}
+		return []reconcile.Request{{
+			NamespacedName: types.NamespacedName{Name: name, Namespace: namespace},
+		}}, nil
+	}
+
+	// ...while source secrets belong to the route owning the certificate that issued them
+	var certificate certmanager.Certificate
+	name := types.NamespacedName{
+		Name:      secret.GetAnnotations()[certificateNameAnnotation],
+		Namespace: secret.GetNamespace(),
+	}
+	if err := r.client.Get(ctx, name, &certificate); err != nil {
+		if apierrs.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to query certificate of TLS secret: %w", err)
+	}
+	owner := metav1.GetControllerOf(&certificate)
+	if owner == nil || owner.Kind != "IngressRoute" {
+		return nil, nil
+	}
+	return []reconcile.Request{{
+		NamespacedName: types.NamespacedName{Name: owner.Name, Namespace: secret.GetNamespace()},
+	}}, nil
+}
+
+func (*reflector) isCopyOf(secret *v1.Secret, owner metav1.Object) bool {
+	return secret.Labels[reflectedNamespaceLabelKey] == owner.GetNamespace() &&
+		secret.Annotations[reflectedRouteAnnotation] == owner.GetName()
+}
@@ -0,0 +1,179 @@
+package integrations
+
+import (
+	"context"
+	"testing"
+
+	"github.com/borchero/switchboard/internal/k8tests"
+	certmanager "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	traefik "github.com/traefik/traefik/v3/pkg/provider/kubernetes/crd/traefikio/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReflectorUpdateResource(t *testing.T) {
+	// Setup
+	ctx := context.Background()
+	scheme := k8tests.NewScheme()
+	client := k8tests.NewClient(t, scheme)
+	namespace, shutdown := k8tests.NewNamespace(ctx, t, client)
+	defer shutdown()
+	target, shutdownTarget := k8tests.NewNamespace(ctx, t, client)
+	defer shutdownTarget()
+
+	// Create a dummy service as owner and the TLS secret
+	owner := k8tests.DummyService("my-service", namespace, 80)
+	err := client.Create(ctx, &owner)
+	require.Nil(t, err)
+	tlsName := "test-tls"
+	source := v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        tlsName,
+			Namespace:   namespace,
+			Annotations: map[string]string{certificateNameAnnotation: certificateName(&owner)},
+		},
+		Data: map[string][]byte{"tls.crt": []byte("certificate")},
+	}
+	err = client.Create(ctx, &source)
+	require.Nil(t, err)
+	integration := NewReflector(client, []string{target})
+	info := IngressInfo{Hosts: []string{"example.com"}, TLSSecretName: &tlsName}
+	copyName := types.NamespacedName{Name: tlsName, Namespace: target}
+
+	// Namespaces that are not allowed must be rejected
+	owner.Annotations = map[string]string{"switchboard.borchero.com/reflect-tls-to": "default"}
+	err = integration.UpdateResource(ctx, &owner, info)
+	assert.NotNil(t, err)
+
+	// The secret should be copied to the target namespace
+	owner.Annotations = map[string]string{"switchboard.borchero.com/reflect-tls-to": target}
+	err = integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	var secret v1.Secret
+	err = client.Get(ctx, copyName, &secret)
+	require.Nil(t, err)
+	assert.Equal(t, source.Data, secret.Data)
+
+	// The copy should be updated on renewal
+	source.Data = map[string][]byte{"tls.crt": []byte("renewed")}
+	err = client.Update(ctx, &source)
+	require.Nil(t, err)
+	err = integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	err = client.Get(ctx, copyName, &secret)
+	require.Nil(t, err)
+	assert.Equal(t, source.Data, secret.Data)
+
+	// The copy should be removed once the annotation is removed
+	owner.Annotations = nil
+	err = integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	err = client.Get(ctx, copyName, &secret)
+	assert.True(t, apierrs.IsNotFound(err))
+}
+
+func TestReflectorIsAllowed(t *testing.T) {
+	integration := reflector{allowedNamespaces: []string{"shared", "tenant-*"}}
+	assert.True(t, integration.isAllowed("shared"))
+	assert.True(t, integration.isAllowed("tenant-a"))
+	assert.False(t, integration.isAllowed("kube-system"))
+}
+
+func TestReflectorValidate(t *testing.T) {
+	integration := NewReflector(nil, []string{"tenant-*"}).(Validator)
+	owner := &metav1.ObjectMeta{Annotations: map[string]string{
+		"switchboard.borchero.com/reflect-tls-to": "tenant-a",
+	}}
+	assert.Nil(t, integration.Validate(context.Background(), owner, IngressInfo{}))
+
+	owner.Annotations["switchboard.borchero.com/reflect-tls-to"] = "tenant-a,kube-system"
+	assert.NotNil(t, integration.Validate(context.Background(), owner, IngressInfo{}))
+}
+
+func TestReflectorWatches(t *testing.T) {
+	integration := NewReflector(nil, nil)
+	watches := integration.Watches()
+	require.Len(t, watches, 1)
+	require.Len(t, watches[0].Predicates, 1)
+	assert.IsType(t, &metav1.PartialObjectMetadata{}, watches[0].Object)
+	predicate := watches[0].Predicates[0]
+
+	// Unrelated secrets are ignored
+	secret := &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Name: "my-secret"}}
+	assert.False(t, predicate.Create(event.CreateEvent{Object: secret}))
+
+	// Renewals of source secrets are relevant
+	source := &metav1.PartialObjectMetadata{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations:     map[string]string{certificateNameAnnotation: "my-route-tls"},
+			ResourceVersion: "1",
+		},
+	}
+	renewed := source.DeepCopy()
+	renewed.ResourceVersion = "2"
+	assert.True(t, predicate.Create(event.CreateEvent{Object: source}))
+	assert.False(t, predicate.Update(event.UpdateEvent{ObjectOld: source, ObjectNew: source}))
+	assert.True(t, predicate.Update(event.UpdateEvent{ObjectOld: source, ObjectNew: renewed}))
+
+	// Deletions of copies are relevant
+	copied := &metav1.PartialObjectMetadata{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{reflectedNamespaceLabelKey: "my-namespace"},
+		},
+	}
+	assert.True(t, predicate.Delete(event.DeleteEvent{Object: copied}))
+}
+
+func TestReflectorRouteOf(t *testing.T) {
+	ctx := context.Background()
+	route := traefik.IngressRoute{ObjectMeta: metav1.ObjectMeta{
+		Name: "my-route", Namespace: "default", UID: "1234",
+	}}
+	certificate := certmanager.Certificate{ObjectMeta: metav1.ObjectMeta{
+		Name:      certificateName(&route),
+		Namespace: route.Namespace,
+	}}
+	scheme := k8tests.NewScheme()
+	err := ctrl.SetControllerReference(&route, &certificate, scheme)
+	require.Nil(t, err)
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&certificate).Build()
+	integration := reflector{client: client}
+	expected := []reconcile.Request{{
+		NamespacedName: types.NamespacedName{Name: route.Name, Namespace: route.Namespace},
+	}}
+
+	// Source secrets map to the route owning their certificate
+	source := &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{
+		Name:        "www-tls",
+		Namespace:   route.Namespace,
+		Annotations: map[string]string{certificateNameAnnotation: certificate.Name},
+	}}
+	requests, err := integration.routeOf(ctx, source)
+	require.Nil(t, err)
+	assert.Equal(t, expected, requests)
+
+	// Copies map to the route they were reflected from
+	copied := &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{
+		Name:        "www-tls",
+		Namespace:   "shared",
+		Labels:      map[string]string{reflectedNamespaceLabelKey: route.Namespace},
+		Annotations: map[string]string{reflectedRouteAnnotation: route.Name},
+	}}
+	requests, err = integration.routeOf(ctx, copied)
+	require.Nil(t, err)
+	assert.Equal(t, expected, requests)
+
+	// Secrets of unknown certificates do not map to any route
+	source.Annotations[certificateNameAnnotation] = "unknown"
+	requests, err = integration.routeOf(ctx, source)
+	require.Nil(t, err)
+	assert.Empty(t, requests)
+}
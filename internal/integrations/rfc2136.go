@@ -0,0 +1,431 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/borchero/switchboard/internal/ext"
+	"github.com/borchero/switchboard/internal/k8s"
+	"github.com/borchero/switchboard/internal/switchboard"
+	"github.com/miekg/dns"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	rfc2136RegistryPrefix   = "_switchboard."
+	rfc2136DefaultTSIGAlgo  = dns.HmacSHA256
+	rfc2136DefaultTTL       = 300
+	rfc2136ExchangeTimeout  = 10 * time.Second
+	rfc2136TSIGFudgeSeconds = 300
+	rfc2136RegistryCacheTTL = time.Minute
+)
+
+// RFC2136Server describes a DNS server that accepts dynamic updates as described in RFC 2136 for a
+// single zone. If a TSIG key name is set, updates and zone transfers are signed with the TSIG
+// secret (base64-encoded) using the given algorithm (defaulting to HMAC-SHA256).
+type RFC2136Server struct {
+	Address       string
+	Zone          string
+	TSIGKeyName   string
+	TSIGSecret    string
+	TSIGAlgorithm string
+}
+
+type rfc2136 struct {
+	client  client.Client
+	target  switchboard.Target
+	server  RFC2136Server
+	ownerID string
+	ttl     uint32
+
+	// The registry records of the zone (registry name -> owner value) which are obtained via
+	// zone transfers and kept in sync with the updates that are sent by this integration
+	mutex       sync.Mutex
+	registry    map[string]string
+	transferred time.Time
+}
+
+// NewRFC2136 initializes a new integration which directly manages DNS records on the provided
+// server via dynamic updates, pointing all hosts to the given target. If ttl is nil, a default TTL
+// of 300 seconds is used. Ownership of records is tracked via TXT registry records (prefixed with
+// `_switchboard.`) which are tagged with the given owner ID. Hosts outside of the server's zone
+// are ignored. The registry records are obtained via zone transfers which are cached for a minute,
+// all other records are queried individually.
+func NewRFC2136(
+	client client.Client, target switchboard.Target, server RFC2136Server, ownerID string,
+	ttl *int64,
+) Integration {
+	server.Zone = dns.Fqdn(server.Zone)
+	if server.TSIGKeyName != "" {
+		server.TSIGKeyName = dns.Fqdn(server.TSIGKeyName)
+		if server.TSIGAlgorithm == "" {
+			server.TSIGAlgorithm = rfc2136DefaultTSIGAlgo
+		}
+		server.TSIGAlgorithm = dns.Fqdn(server.TSIGAlgorithm)
+	}
+	ttlValue := uint32(rfc2136DefaultTTL)
+	if ttl != nil {
+		ttlValue = uint32(*ttl)
+	}
+	return &rfc2136{client: client, target: target, server: server, ownerID: ownerID, ttl: ttlValue}
+}
+
+func (*rfc2136) Name() string {
+	return "rfc2136"
+}
+
+func (*rfc2136) OwnedResource() client.Object {
+	return nil
+}
+
+func (r *rfc2136) Watches() []k8s.Watch {
+	return r.target.Watches()
+}
+
+func (r *rfc2136) ResolveTargets(ctx context.Context) ([]string, error) {
+	return r.target.Targets(ctx, r.client)
+}
+
+func (r *rfc2136) UpdateResource(
+	ctx context.Context, owner metav1.Object, info IngressInfo,
+) error {
+	// First, we need to find out which records currently exist for the hosts that we want to
+	// manage and the ones that we managed previously
+	ownerValue := r.registryValue(owner)
+	names, err := r.ownedHosts(ctx, ownerValue)
+	if err != nil {
+		return err
+	}
+	for _, host := range info.Hosts {
+		name := dns.Fqdn(strings.ToLower(host))
+		if dns.IsSubDomain(r.server.Zone, name) && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	records, err := r.queryRecords(ctx, names)
+	if err != nil {
+		return fmt.Errorf("failed to query DNS records: %w", err)
+	}
+
+	// Then, we find the hosts that we want to manage and make sure that we do not touch any records
+	// that are owned by someone else
+	hosts := make([]string, 0, len(info.Hosts))
+	for _, host := range info.Hosts {
+		name := dns.Fqdn(strings.ToLower(host))
+		if !dns.IsSubDomain(r.server.Zone, name) {
+			continue
+		}
+		owned := false
+		for _, rr := range records {
+			txt, ok := rr.(*dns.TXT)
+			if !ok || !strings.EqualFold(txt.Hdr.Name, r.registryName(name)) {
+				continue
+			}
+			if value := strings.Join(txt.Txt, ""); value != ownerValue {
+				return fmt.Errorf("host %q is owned by %q", host, value)
+			}
+			owned = true
+		}
+		if !owned && slices.ContainsFunc(records, func(rr dns.RR) bool {
+			return strings.EqualFold(rr.Header().Name, name) && isManagedRecordType(rr)
+		}) {
+			return fmt.Errorf("host %q has records which are not managed by switchboard", host)
+		}
+		hosts = append(hosts, name)
+	}
+
+	// Now, we can compute the records that should exist for the managed hosts...
+	desired := make([]dns.RR, 0)
+	if len(hosts) > 0 {
+		targets, err := r.target.Targets(ctx, r.client)
+		if err != nil {
+			return fmt.Errorf("failed to query targets: %w", err)
+		}
+		for _, host := range hosts {
+			desired = append(desired, r.records(host, targets, ownerValue)...)
+		}
+	}
+
+	// ...and the ones that exist for hosts that we previously managed
+	current := make([]dns.RR, 0)
+	for _, rr := range records {
+		txt, ok := rr.(*dns.TXT)
+		if !ok || strings.Join(txt.Txt, "") != ownerValue {
+			continue
+		}
+		host := r.hostFromRegistryName(txt.Hdr.Name)
+		for _, other := range records {
+			if strings.EqualFold(other.Header().Name, host) && isManagedRecordType(other) {
+				current = append(current, other)
+			}
+		}
+		current = append(current, txt)
+	}
+
+	// Eventually, we send an update if anything changed
+	if equalRecords(current, desired) {
+		return nil
+	}
+	if isDryRun(ctx) {
+		return nil
+	}
+	update := new(dns.Msg)
+	update.SetUpdate(r.server.Zone)
+	if len(current) > 0 {
+		update.RemoveRRset(current)
+	}
+	if len(desired) > 0 {
+		update.RemoveRRset(desired)
+		update.Insert(desired)
+	}
+	if err := r.exchange(ctx, update); err != nil {
+		// The update might have been applied nonetheless, the registry must be transferred again
+		r.resetRegistry()
+		return fmt.Errorf("failed to update DNS records: %w", err)
+	}
+	r.updateRegistry(current, desired)
+	return nil
+}
+
+//-------------------------------------------------------------------------------------------------
+// UTILS
+//-------------------------------------------------------------------------------------------------
+
+func (r *rfc2136) records(host string, targets []string, ownerValue string) []dns.RR {
+	records := make([]dns.RR, 0, len(targets)+1)
+	for _, target := range targets {
+		header := dns.RR_Header{Name: host, Class: dns.ClassINET, Ttl: r.ttl}
+		switch targetRecordType(target) {
+		case "A":
+			header.Rrtype = dns.TypeA
+			records = append(records, &dns.A{Hdr: header, A: net.ParseIP(target)})
+		case "AAAA":
+			header.Rrtype = dns.TypeAAAA
+			records = append(records, &dns.AAAA{Hdr: header, AAAA: net.ParseIP(target)})
+		default:
+			header.Rrtype = dns.TypeCNAME
+			records = append(records, &dns.CNAME{Hdr: header, Target: dns.Fqdn(target)})
+		}
+	}
+	records = append(records, &dns.TXT{
+		Hdr: dns.RR_Header{
+			Name: r.registryName(host), Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: r.ttl,
+		},
+		Txt: []string{ownerValue},
+	})
+	return records
+}
+
+func (*rfc2136) registryName(host string) string {
+	return rfc2136RegistryPrefix + strings.Replace(host, "*", "wildcard", 1)
+}
+
+func (*rfc2136) hostFromRegistryName(name string) string {
+	host := strings.TrimPrefix(strings.ToLower(name), rfc2136RegistryPrefix)
+	if strings.HasPrefix(host, "wildcard.") {
+		return "*" + strings.TrimPrefix(host, "wildcard")
+	}
+	return host
+}
+
+func (r *rfc2136) registryValue(owner metav1.Object) string {
+	return fmt.Sprintf(
+		"heritage=switchboard,switchboard/owner=%s,switchboard/resource=ingressroute/%s/%s",
+		r.ownerID, owner.GetNamespace(), owner.GetName(),
+	)
+}
+
+func (r *rfc2136) ownedHosts(ctx context.Context, ownerValue string) ([]string, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.registry == nil || time.Since(r.transferred) > rfc2136RegistryCacheTTL {
+		records, err := r.transferZone(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to transfer zone: %w", err)
+		}
+		r.registry = make(map[string]string)
+		for _, rr := range records {
+			txt, ok := rr.(*dns.TXT)
+			name := strings.ToLower(rr.Header().Name)
+			if ok && strings.HasPrefix(name, rfc2136RegistryPrefix) {
+				r.registry[name] = strings.Join(txt.Txt, "")
+			}
+		}
+		r.transferred = time.Now()
+	}
+
+	hosts := make([]string, 0)
+	for name, value := range r.registry {
+		if value == ownerValue {
+			hosts = append(hosts, r.hostFromRegistryName(name))
+		}
+	}
+	return hosts, nil
+}
+
+func (r *rfc2136) updateRegistry(removed, inserted []dns.RR) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.registry == nil {
+		return
+	}
+	for _, rr := range removed {
+		if _, ok := rr.(*dns.TXT); ok {
+			delete(r.registry, strings.ToLower(rr.Header().Name))
+		}
+	}
+	for _, rr := range inserted {
+		if txt, ok := rr.(*dns.TXT); ok {
+			r.registry[strings.ToLower(txt.Hdr.Name)] = strings.Join(txt.Txt, "")
+		}
+	}
+}
+
+func (r *rfc2136) resetRegistry() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.registry = nil
+}
+
+func (r *rfc2136) transferZone(ctx context.Context) ([]dns.RR, error) {
+	conn, err := r.dnsClient().DialContext(ctx, r.server.Address)
+	if err != nil {
+		return nil, err
+	}
+	// Closing the connection aborts the transfer once the context is cancelled
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	msg := new(dns.Msg)
+	msg.SetAxfr(r.server.Zone)
+	transfer := &dns.Transfer{Conn: conn}
+	if r.server.TSIGKeyName != "" {
+		transfer.TsigSecret = map[string]string{r.server.TSIGKeyName: r.server.TSIGSecret}
+		r.sign(msg)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		transfer.ReadTimeout = time.Until(deadline)
+	}
+
+	envelopes, err := transfer.In(msg, r.server.Address)
+	if err != nil {
+		_ = conn.Close()
+		return nil, errors.Join(ctx.Err(), err)
+	}
+	records := make([]dns.RR, 0)
+	for envelope := range envelopes {
+		if envelope.Error != nil {
+			return nil, errors.Join(ctx.Err(), envelope.Error)
+		}
+		records = append(records, envelope.RR...)
+	}
+	return records, nil
+}
+
+func (r *rfc2136) queryRecords(ctx context.Context, hosts []string) ([]dns.RR, error) {
+	// For each host, we query the registry record as well as all managed record types
+	records := make([]dns.RR, 0)
+	for _, host := range hosts {
+		questions := []dns.Question{
+			{Name: r.registryName(host), Qtype: dns.TypeTXT, Qclass: dns.ClassINET},
+			{Name: host, Qtype: dns.TypeA, Qclass: dns.ClassINET},
+			{Name: host, Qtype: dns.TypeAAAA, Qclass: dns.ClassINET},
+			{Name: host, Qtype: dns.TypeCNAME, Qclass: dns.ClassINET},
+		}
+		for _, question := range questions {
+			msg := new(dns.Msg)
+			msg.SetQuestion(question.Name, question.Qtype)
+			response, err := r.send(ctx, msg)
+			if err != nil {
+				return nil, err
+			}
+			if response.Rcode != dns.RcodeSuccess && response.Rcode != dns.RcodeNameError {
+				return nil, fmt.Errorf("server responded with %s", dns.RcodeToString[response.Rcode])
+			}
+			for _, rr := range response.Answer {
+				if strings.EqualFold(rr.Header().Name, question.Name) &&
+					rr.Header().Rrtype == question.Qtype {
+					records = append(records, rr)
+				}
+			}
+		}
+	}
+	return records, nil
+}
+
+func (r *rfc2136) exchange(ctx context.Context, msg *dns.Msg) error {
+	response, err := r.send(ctx, msg)
+	if err != nil {
+		return err
+	}
+	if response.Rcode != dns.RcodeSuccess {
+		return fmt.Errorf("server responded with %s", dns.RcodeToString[response.Rcode])
+	}
+	return nil
+}
+
+func (r *rfc2136) send(ctx context.Context, msg *dns.Msg) (*dns.Msg, error) {
+	// Every message uses its own connection, signatures of subsequent messages would be chained
+	dnsClient := r.dnsClient()
+	conn, err := dnsClient.DialContext(ctx, r.server.Address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close() // nolint:errcheck
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	if r.server.TSIGKeyName != "" {
+		r.sign(msg)
+	}
+	response, _, err := dnsClient.ExchangeWithConnContext(ctx, msg, conn)
+	if err != nil {
+		return nil, errors.Join(ctx.Err(), err)
+	}
+	return response, nil
+}
+
+func (r *rfc2136) dnsClient() *dns.Client {
+	dnsClient := &dns.Client{Net: "tcp", Timeout: rfc2136ExchangeTimeout}
+	if r.server.TSIGKeyName != "" {
+		dnsClient.TsigSecret = map[string]string{r.server.TSIGKeyName: r.server.TSIGSecret}
+	}
+	return dnsClient
+}
+
+func (r *rfc2136) sign(msg *dns.Msg) {
+	msg.SetTsig(
+		r.server.TSIGKeyName, r.server.TSIGAlgorithm, rfc2136TSIGFudgeSeconds, time.Now().Unix(),
+	)
+}
+
+func isManagedRecordType(rr dns.RR) bool {
+	switch rr.Header().Rrtype {
+	case dns.TypeA, dns.TypeAAAA, dns.TypeCNAME:
+		return true
+	default:
+		return false
+	}
+}
+
+func equalRecords(current, desired []dns.RR) bool {
+	key := func(rr dns.RR) string {
+		copied := dns.Copy(rr)
+		copied.Header().Name = strings.ToLower(copied.Header().Name)
+		return copied.String()
+	}
+	currentKeys := ext.Map(current, key)
+	desiredKeys := ext.Map(desired, key)
+	slices.Sort(currentKeys)
+	slices.Sort(desiredKeys)
+	return slices.Equal(currentKeys, desiredKeys)
+}
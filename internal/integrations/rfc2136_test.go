@@ -0,0 +1,322 @@
+package integrations
+
+import (
+	"context"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/borchero/switchboard/internal/k8tests"
+	"github.com/borchero/switchboard/internal/switchboard"
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testZone       = "example.com."
+	testTSIGKey    = "switchboard."
+	testTSIGSecret = "c3dpdGNoYm9hcmQtdGVzdC1zZWNyZXQ="
+)
+
+func TestRFC2136UpdateResource(t *testing.T) {
+	// Setup
+	ctx := context.Background()
+	server := newTestDNSServer(t)
+	owner := k8tests.DummyService("my-service", "my-namespace", 80)
+	integration := NewRFC2136(nil, switchboard.NewStaticTarget("127.0.0.1"), RFC2136Server{
+		Address:     server.address,
+		Zone:        "example.com",
+		TSIGKeyName: testTSIGKey,
+		TSIGSecret:  testTSIGSecret,
+	}, "default", nil)
+
+	// No records should be created if no hosts are provided
+	var info IngressInfo
+	err := integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	assert.Len(t, server.recordsOfType(dns.TypeA), 0)
+
+	// Records should be created for all hosts in the zone
+	info.Hosts = []string{"example.com", "www.example.com", "example.net"}
+	err = integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	assert.ElementsMatch(
+		t, []string{"example.com.", "www.example.com."}, server.recordsOfType(dns.TypeA),
+	)
+	assert.ElementsMatch(t, []string{
+		"_switchboard.example.com.", "_switchboard.www.example.com.",
+	}, server.recordsOfType(dns.TypeTXT))
+
+	// Updating hosts should remove stale records
+	info.Hosts = []string{"www.example.com", "*.example.com"}
+	err = integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	assert.ElementsMatch(
+		t, []string{"www.example.com.", "*.example.com."}, server.recordsOfType(dns.TypeA),
+	)
+	assert.ElementsMatch(t, []string{
+		"_switchboard.www.example.com.", "_switchboard.wildcard.example.com.",
+	}, server.recordsOfType(dns.TypeTXT))
+
+	// Records owned by another route must not be touched
+	other := k8tests.DummyService("your-service", "my-namespace", 80)
+	err = integration.UpdateResource(ctx, &other, IngressInfo{Hosts: []string{"www.example.com"}})
+	assert.NotNil(t, err)
+
+	// Unmanaged records must not be touched
+	server.insert(t, "api.example.com. 300 IN A 127.0.0.2")
+	err = integration.UpdateResource(ctx, &other, IngressInfo{Hosts: []string{"api.example.com"}})
+	assert.NotNil(t, err)
+
+	// When no hosts are set, all records should be removed
+	updates := server.numUpdates()
+	info.Hosts = nil
+	err = integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	assert.ElementsMatch(t, []string{"api.example.com."}, server.recordsOfType(dns.TypeA))
+	assert.Len(t, server.recordsOfType(dns.TypeTXT), 0)
+	assert.Equal(t, updates+1, server.numUpdates())
+
+	// No update should be sent if nothing changes
+	err = integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	assert.Equal(t, updates+1, server.numUpdates())
+
+	// The zone should have been transferred only once
+	assert.Equal(t, 1, server.numTransfers())
+}
+
+func TestRFC2136UpdateResourceStaleRegistry(t *testing.T) {
+	ctx := context.Background()
+	server := newTestDNSServer(t)
+	owner := k8tests.DummyService("my-service", "my-namespace", 80)
+	integration := NewRFC2136(nil, switchboard.NewStaticTarget("127.0.0.1"), RFC2136Server{
+		Address:     server.address,
+		Zone:        "example.com",
+		TSIGKeyName: testTSIGKey,
+		TSIGSecret:  testTSIGSecret,
+	}, "default", nil).(*rfc2136)
+
+	// Records should be removed once the hosts are removed, even if the registry has expired
+	info := IngressInfo{Hosts: []string{"www.example.com"}}
+	err := integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	integration.transferred = time.Time{}
+	err = integration.UpdateResource(ctx, &owner, IngressInfo{})
+	require.Nil(t, err)
+	assert.Len(t, server.recordsOfType(dns.TypeA), 0)
+	assert.Len(t, server.recordsOfType(dns.TypeTXT), 0)
+	assert.Equal(t, 2, server.numTransfers())
+}
+
+func TestRFC2136TransferZoneCancelled(t *testing.T) {
+	// The server accepts connections but never responds
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer listener.Close() // nolint:errcheck
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close() // nolint:errcheck
+		}
+	}()
+
+	integration := NewRFC2136(nil, nil, RFC2136Server{
+		Address: listener.Addr().String(),
+		Zone:    "example.com",
+	}, "default", nil).(*rfc2136)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	_, err = integration.transferZone(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestRFC2136UpdateResourceUnauthorized(t *testing.T) {
+	ctx := context.Background()
+	server := newTestDNSServer(t)
+	owner := k8tests.DummyService("my-service", "my-namespace", 80)
+	integration := NewRFC2136(nil, switchboard.NewStaticTarget("127.0.0.1"), RFC2136Server{
+		Address: server.address,
+		Zone:    "example.com",
+	}, "default", nil)
+
+	err := integration.UpdateResource(ctx, &owner, IngressInfo{Hosts: []string{"example.com"}})
+	assert.NotNil(t, err)
+	assert.Len(t, server.recordsOfType(dns.TypeA), 0)
+}
+
+func TestRFC2136Records(t *testing.T) {
+	integration := NewRFC2136(
+		nil, nil, RFC2136Server{Zone: "example.com"}, "default", nil,
+	).(*rfc2136)
+
+	records := integration.records(
+		"www.example.com.", []string{"127.0.0.1", "2001:db8::1"}, "owner",
+	)
+	require.Len(t, records, 3)
+	assert.Equal(t, "www.example.com.\t300\tIN\tA\t127.0.0.1", records[0].String())
+	assert.Equal(t, "www.example.com.\t300\tIN\tAAAA\t2001:db8::1", records[1].String())
+	assert.Equal(
+		t, "_switchboard.www.example.com.\t300\tIN\tTXT\t\"owner\"", records[2].String(),
+	)
+
+	records = integration.records(
+		"www.example.com.", []string{"example.lb.identifier.amazonaws.com"}, "owner",
+	)
+	require.Len(t, records, 2)
+	assert.Equal(
+		t,
+		"www.example.com.\t300\tIN\tCNAME\texample.lb.identifier.amazonaws.com.",
+		records[0].String(),
+	)
+}
+
+func TestRFC2136RegistryName(t *testing.T) {
+	var integration rfc2136
+	for _, host := range []string{"example.com.", "*.example.com."} {
+		assert.Equal(t, host, integration.hostFromRegistryName(integration.registryName(host)))
+	}
+	assert.Equal(
+		t, "_switchboard.wildcard.example.com.", integration.registryName("*.example.com."),
+	)
+}
+
+//-------------------------------------------------------------------------------------------------
+// UTILS
+//-------------------------------------------------------------------------------------------------
+
+type testDNSServer struct {
+	address   string
+	mutex     sync.Mutex
+	records   []dns.RR
+	updates   int
+	transfers int
+}
+
+func newTestDNSServer(t *testing.T) *testDNSServer {
+	soa, err := dns.NewRR(testZone + " 300 IN SOA ns.example.com. admin.example.com. 1 60 60 60 60")
+	require.Nil(t, err)
+	server := &testDNSServer{records: []dns.RR{soa}}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	server.address = listener.Addr().String()
+
+	started := make(chan struct{})
+	dnsServer := &dns.Server{
+		Listener:          listener,
+		Handler:           server,
+		TsigSecret:        map[string]string{testTSIGKey: testTSIGSecret},
+		NotifyStartedFunc: func() { close(started) },
+		MsgAcceptFunc: func(dns.Header) dns.MsgAcceptAction {
+			return dns.MsgAccept
+		},
+	}
+	go dnsServer.ActivateAndServe() // nolint:errcheck
+	<-started
+	t.Cleanup(func() {
+		dnsServer.Shutdown() // nolint:errcheck
+	})
+	return server
+}
+
+func (s *testDNSServer) ServeDNS(w dns.ResponseWriter, req *dns.Msg) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	response := new(dns.Msg)
+	response.SetReply(req)
+
+	// Reject all requests that are not signed
+	if req.IsTsig() == nil || w.TsigStatus() != nil {
+		response.SetRcode(req, dns.RcodeRefused)
+		w.WriteMsg(response) // nolint:errcheck
+		return
+	}
+	response.SetTsig(testTSIGKey, dns.HmacSHA256, 300, int64(req.IsTsig().TimeSigned))
+
+	switch {
+	case req.Opcode == dns.OpcodeUpdate:
+		s.update(req.Ns)
+		w.WriteMsg(response) // nolint:errcheck
+	case len(req.Question) == 1 && req.Question[0].Qtype == dns.TypeAXFR:
+		s.transfers++
+		records := append(append([]dns.RR{}, s.records...), s.records[0])
+		envelopes := make(chan *dns.Envelope, 1)
+		envelopes <- &dns.Envelope{RR: records}
+		close(envelopes)
+		transfer := new(dns.Transfer)
+		transfer.Out(w, req, envelopes) // nolint:errcheck
+		w.Hijack()
+	case req.Opcode == dns.OpcodeQuery && len(req.Question) == 1:
+		question := req.Question[0]
+		for _, rr := range s.records {
+			if strings.EqualFold(rr.Header().Name, question.Name) &&
+				rr.Header().Rrtype == question.Qtype {
+				response.Answer = append(response.Answer, rr)
+			}
+		}
+		w.WriteMsg(response) // nolint:errcheck
+	default:
+		response.SetRcode(req, dns.RcodeNotImplemented)
+		w.WriteMsg(response) // nolint:errcheck
+	}
+}
+
+func (s *testDNSServer) update(changes []dns.RR) {
+	s.updates++
+	for _, change := range changes {
+		header := change.Header()
+		switch header.Class {
+		case dns.ClassANY:
+			// Remove RRset
+			s.records = slices.DeleteFunc(s.records, func(rr dns.RR) bool {
+				return strings.EqualFold(rr.Header().Name, header.Name) &&
+					rr.Header().Rrtype == header.Rrtype
+			})
+		case dns.ClassINET:
+			s.records = append(s.records, change)
+		}
+	}
+}
+
+func (s *testDNSServer) insert(t *testing.T, record string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	rr, err := dns.NewRR(record)
+	require.Nil(t, err)
+	s.records = append(s.records, rr)
+}
+
+func (s *testDNSServer) recordsOfType(rrtype uint16) []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	names := make([]string, 0)
+	for _, rr := range s.records {
+		if rr.Header().Rrtype == rrtype {
+			names = append(names, rr.Header().Name)
+		}
+	}
+	return names
+}
+
+func (s *testDNSServer) numUpdates() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.updates
+}
+
+func (s *testDNSServer) numTransfers() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.transfers
+}
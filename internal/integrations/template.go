@@ -0,0 +1,189 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/borchero/switchboard/internal/k8s"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+var templateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"quote": strconv.Quote,
+}
+
+// TemplateData is the data that resource templates are rendered with.
+type TemplateData struct {
+	// Name is the name of the rendered resource.
+	Name string
+	// Namespace is the namespace of the rendered resource (and the ingress).
+	Namespace string
+	// Route is the metadata of the ingress.
+	Route metav1.ObjectMeta
+	// Hosts are the hosts of the ingress.
+	Hosts []string
+	// TLSSecretName is the name of the TLS secret of the ingress or empty if TLS is not used.
+	TLSSecretName string
+	// EntryPoints are the Traefik entry points that the ingress is bound to.
+	EntryPoints []string
+}
+
+type templateIntegration struct {
+	client   client.Client
+	name     string
+	gvk      schema.GroupVersionKind
+	template *template.Template
+}
+
+// NewTemplate initializes a new integration with the given name which creates a resource of the
+// given kind for every ingress by rendering the provided Go template (see `TemplateData` for the
+// available data). The rendered manifest must be YAML or JSON. Its name and namespace are always
+// set to `<ingress>-<name>` and the namespace of the ingress, respectively. If the template renders
+// to an empty manifest, the resource is deleted.
+func NewTemplate(
+	client client.Client, name string, gvk schema.GroupVersionKind, manifest string,
+) (Integration, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").
+		Parse(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %s", err)
+	}
+	return &templateIntegration{client, name, gvk, tmpl}, nil
+}
+
+func (t *templateIntegration) Name() string {
+	return t.name
+}
+
+func (t *templateIntegration) OwnedResource() client.Object {
+	resource := &unstructured.Unstructured{}
+	resource.SetGroupVersionKind(t.gvk)
+	return resource
+}
+
+func (*templateIntegration) Watches() []k8s.Watch {
+	return nil
+}
+
+func (t *templateIntegration) UpdateResource(
+	ctx context.Context, owner metav1.Object, info IngressInfo,
+) error {
+	// First, we render the manifest...
+	rendered, err := t.render(owner, info)
+	if err != nil {
+		return fmt.Errorf("failed to render template: %w", err)
+	}
+
+	// ...which must be deleted if it is empty...
+	resource := t.emptyResource(owner)
+	if rendered == nil {
+		if err := deleteIfFound(ctx, t.client, resource); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", t.gvk.Kind, err)
+		}
+		return nil
+	}
+
+	// ...and created otherwise
+	if _, err := createOrPatch(ctx, t.client, resource, func() error {
+		// Meta
+		if err := reconcileMetadata(owner, resource, t.client.Scheme(), rendered); err != nil {
+			return fmt.Errorf("failed to reconcile metadata: %s", err)
+		}
+
+		// Contents
+		t.reconcileContents(resource, rendered)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to upsert %s: %w", t.gvk.Kind, err)
+	}
+	return nil
+}
+
+//-------------------------------------------------------------------------------------------------
+// UTILS
+//-------------------------------------------------------------------------------------------------
+
+func (t *templateIntegration) emptyResource(owner metav1.Object) *unstructured.Unstructured {
+	resource := &unstructured.Unstructured{}
+	resource.SetGroupVersionKind(t.gvk)
+	resource.SetName(fmt.Sprintf("%s-%s", owner.GetName(), t.name))
+	resource.SetNamespace(owner.GetNamespace())
+	return resource
+}
+
+// reconcileContents replaces all top-level fields of the resource (except for its metadata and
+// status) with the ones of the rendered manifest. Fields that are not rendered anymore are removed.
+func (*templateIntegration) reconcileContents(resource, rendered *unstructured.Unstructured) {
+	isContent := func(key string) bool {
+		switch key {
+		case "apiVersion", "kind", "metadata", "status":
+			return false
+		default:
+			return true
+		}
+	}
+	for key := range resource.Object {
+		if _, ok := rendered.Object[key]; !ok && isContent(key) {
+			delete(resource.Object, key)
+		}
+	}
+	for key, value := range rendered.Object {
+		if isContent(key) {
+			resource.Object[key] = value
+		}
+	}
+}
+
+func (t *templateIntegration) render(
+	owner metav1.Object, info IngressInfo,
+) (*unstructured.Unstructured, error) {
+	resource := t.emptyResource(owner)
+	data := TemplateData{
+		Name:      resource.GetName(),
+		Namespace: resource.GetNamespace(),
+		Route: metav1.ObjectMeta{
+			Name:        owner.GetName(),
+			Namespace:   owner.GetNamespace(),
+			Labels:      owner.GetLabels(),
+			Annotations: owner.GetAnnotations(),
+		},
+		Hosts:       info.Hosts,
+		EntryPoints: info.EntryPoints,
+	}
+	if info.TLSSecretName != nil {
+		data.TLSSecretName = *info.TLSSecretName
+	}
+
+	var buffer bytes.Buffer
+	if err := t.template.Execute(&buffer, data); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(buffer.String()) == "" {
+		return nil, nil
+	}
+
+	var object map[string]any
+	if err := yaml.Unmarshal(buffer.Bytes(), &object); err != nil {
+		return nil, fmt.Errorf("failed to parse rendered manifest: %s", err)
+	}
+	if object == nil {
+		return nil, nil
+	}
+	rendered := &unstructured.Unstructured{Object: object}
+	if kind := rendered.GroupVersionKind(); !kind.Empty() && kind != t.gvk {
+		return nil, fmt.Errorf("rendered manifest has kind %s but expected %s", kind, t.gvk)
+	}
+	rendered.SetGroupVersionKind(t.gvk)
+	rendered.SetName(resource.GetName())
+	rendered.SetNamespace(resource.GetNamespace())
+	return rendered, nil
+}
@@ -0,0 +1,137 @@
+package integrations
+
+import (
+	"context"
+	"testing"
+
+	"github.com/borchero/switchboard/internal/k8tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testConfigMapTemplate = `{{ if .Hosts }}
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  labels:
+    route: {{ .Route.Name }}
+data:
+  hosts: {{ join .Hosts "," | quote }}
+  tls: {{ quote .TLSSecretName }}
+{{ end }}`
+
+var configMapGVK = schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+
+func TestTemplateUpdateResource(t *testing.T) {
+	// Setup
+	ctx := context.Background()
+	scheme := k8tests.NewScheme()
+	client := k8tests.NewClient(t, scheme)
+	namespace, shutdown := k8tests.NewNamespace(ctx, t, client)
+	defer shutdown()
+
+	owner := k8tests.DummyService("my-service", namespace, 80)
+	err := client.Create(ctx, &owner)
+	require.Nil(t, err)
+	integration, err := NewTemplate(client, "hosts", configMapGVK, testConfigMapTemplate)
+	require.Nil(t, err)
+	name := types.NamespacedName{Name: "my-service-hosts", Namespace: namespace}
+
+	// No resource should be created if the template renders to nothing
+	var info IngressInfo
+	err = integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	var configMap v1.ConfigMap
+	err = client.Get(ctx, name, &configMap)
+	assert.True(t, apierrs.IsNotFound(err))
+
+	// The resource should be created from the template
+	info.Hosts = []string{"example.com", "www.example.com"}
+	err = integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	err = client.Get(ctx, name, &configMap)
+	require.Nil(t, err)
+	assert.Equal(t, "example.com,www.example.com", configMap.Data["hosts"])
+	assert.Equal(t, owner.Name, configMap.Labels["route"])
+
+	// The resource should be removed once the template renders to nothing
+	info.Hosts = nil
+	err = integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	err = client.Get(ctx, name, &configMap)
+	assert.True(t, apierrs.IsNotFound(err))
+}
+
+func TestTemplateRemovesStaleFields(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClientBuilder().WithScheme(k8tests.NewScheme()).Build()
+	owner := k8tests.DummyService("my-service", "default", 80)
+	integration, err := NewTemplate(client, "tls", configMapGVK, `apiVersion: v1
+kind: ConfigMap
+{{ if .TLSSecretName }}
+data:
+  tls: {{ quote .TLSSecretName }}
+{{ end }}`)
+	require.Nil(t, err)
+	key := types.NamespacedName{Name: "my-service-tls", Namespace: "default"}
+
+	// Rendered fields should be set...
+	tlsName := "my-tls"
+	info := IngressInfo{Hosts: []string{"example.com"}, TLSSecretName: &tlsName}
+	err = integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	var configMap v1.ConfigMap
+	err = client.Get(ctx, key, &configMap)
+	require.Nil(t, err)
+	assert.Equal(t, map[string]string{"tls": "my-tls"}, configMap.Data)
+
+	// ...and removed once they are not rendered anymore
+	info.TLSSecretName = nil
+	err = integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	err = client.Get(ctx, key, &configMap)
+	require.Nil(t, err)
+	assert.Empty(t, configMap.Data)
+}
+
+func TestTemplateRender(t *testing.T) {
+	owner := k8tests.DummyService("my-service", "my-namespace", 80)
+	integration, err := NewTemplate(nil, "hosts", configMapGVK, testConfigMapTemplate)
+	require.Nil(t, err)
+	tmpl := integration.(*templateIntegration)
+
+	// Empty manifests
+	rendered, err := tmpl.render(&owner, IngressInfo{})
+	require.Nil(t, err)
+	assert.Nil(t, rendered)
+
+	// Valid manifests
+	tlsName := "my-tls"
+	rendered, err = tmpl.render(&owner, IngressInfo{
+		Hosts: []string{"example.com"}, TLSSecretName: &tlsName,
+	})
+	require.Nil(t, err)
+	assert.Equal(t, "my-service-hosts", rendered.GetName())
+	assert.Equal(t, "my-namespace", rendered.GetNamespace())
+	assert.Equal(t, configMapGVK, rendered.GroupVersionKind())
+	assert.Equal(t, map[string]any{"hosts": "example.com", "tls": "my-tls"}, rendered.Object["data"])
+
+	// Manifests of another kind
+	integration, err = NewTemplate(nil, "hosts", configMapGVK, "apiVersion: v1\nkind: Secret")
+	require.Nil(t, err)
+	_, err = integration.(*templateIntegration).render(&owner, IngressInfo{})
+	assert.NotNil(t, err)
+
+	// Invalid templates
+	_, err = NewTemplate(nil, "hosts", configMapGVK, "{{ .Hosts ")
+	assert.NotNil(t, err)
+	integration, err = NewTemplate(nil, "hosts", configMapGVK, "{{ .Unknown }}")
+	require.Nil(t, err)
+	_, err = integration.(*templateIntegration).render(&owner, IngressInfo{})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,420 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/borchero/switchboard/internal/k8s"
+	"github.com/borchero/switchboard/internal/switchboard"
+	traefik "github.com/traefik/traefik/v3/pkg/provider/kubernetes/crd/traefikio/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	tlsStoreEntriesAnnotationKey = "switchboard.borchero.com/tls-store-entries"
+	tlsOptionRoutesAnnotationKey = "switchboard.borchero.com/tls-option-routes"
+	// Traefik only supports a single TLS store which must be named `default`
+	tlsStoreName = "default"
+)
+
+// tlsStoreEntry describes the TLS secret that an ingress route registers in a TLS store.
+type tlsStoreEntry struct {
+	SecretName string `json:"secretName"`
+	Default    bool   `json:"default,omitempty"`
+}
+
+type tlsStore struct {
+	client    client.Client
+	namespace string
+	mode      switchboard.TLSStoreMode
+	options   map[string]traefik.TLSOptionSpec
+}
+
+// TLSStoreOption allows to customize the TLS store integration.
+type TLSStoreOption func(*tlsStore)
+
+// WithTLSOptions sets the TLS option profiles that ingress routes may bind to by referencing
+// them (by name) via `spec.tls.options`. The integration then maintains a `TLSOption` with the
+// profile's spec in the namespace of the ingress route for as long as it is referenced.
+func WithTLSOptions(options map[string]traefik.TLSOptionSpec) TLSStoreOption {
+	return func(t *tlsStore) {
+		t.options = options
+	}
+}
+
+// NewTLSStore initializes a new integration which registers the TLS secrets that cert-manager
+// issues for ingress routes in the `default` Traefik TLS store in the given namespace. As Traefik
+// only supports a single TLS store and resolves its certificates in the namespace of the TLS
+// store, only ingress routes in this namespace are registered. The provided mode is used for all
+// ingress routes that do not set the `switchboard.borchero.com/tls-store` annotation.
+//
+// As a TLS store is shared by all ingress routes, the entries managed by Switchboard are tracked
+// via an annotation on the TLS store. Certificates that were added to the TLS store by other means
+// are never modified. The same holds for the TLS options that ingress routes are bound to.
+func NewTLSStore(
+	client client.Client,
+	namespace string,
+	mode switchboard.TLSStoreMode,
+	options ...TLSStoreOption,
+) Integration {
+	integration := &tlsStore{client: client, namespace: namespace, mode: mode}
+	for _, option := range options {
+		option(integration)
+	}
+	return integration
+}
+
+func (*tlsStore) Name() string {
+	return "tls-store"
+}
+
+func (*tlsStore) OwnedResource() client.Object {
+	return nil
+}
+
+func (*tlsStore) Watches() []k8s.Watch {
+	return nil
+}
+
+func (t *tlsStore) UpdateResource(
+	ctx context.Context, owner metav1.Object, info IngressInfo,
+) error {
+	// Only certificates that are issued by cert-manager are registered and bound to TLS options
+	issued := issuesCertificate(owner, info)
+	if err := t.updateStore(ctx, owner, info, issued); err != nil {
+		return fmt.Errorf("failed to update TLS store: %w", err)
+	}
+	var option *string
+	if issued && info.TLSOptionName != nil {
+		if _, ok := t.options[*info.TLSOptionName]; ok {
+			option = info.TLSOptionName
+		}
+	}
+	if err := t.updateOptions(ctx, owner, option); err != nil {
+		return fmt.Errorf("failed to update TLS options: %w", err)
+	}
+	return nil
+}
+
+func (t *tlsStore) updateStore(
+	ctx context.Context, owner metav1.Object, info IngressInfo, issued bool,
+) error {
+	// First, we find out whether the TLS secret should be registered...
+	mode, err := switchboard.TLSStoreModeFromAnnotations(owner.GetAnnotations(), t.mode)
+	if err != nil {
+		return fmt.Errorf("invalid TLS store annotation: %s", err)
+	}
+	if owner.GetNamespace() != t.namespace {
+		// The TLS store cannot reference secrets of other namespaces, we thus only fail if the
+		// ingress route explicitly requests to be registered
+		requested, _ := switchboard.TLSStoreModeFromAnnotations(
+			owner.GetAnnotations(), switchboard.TLSStoreModeNone,
+		)
+		if issued && requested != switchboard.TLSStoreModeNone {
+			return fmt.Errorf(
+				"only ingress routes in namespace %q can be registered in the TLS store",
+				t.namespace,
+			)
+		}
+		return nil
+	}
+	var entry *tlsStoreEntry
+	if issued {
+		switch mode {
+		case switchboard.TLSStoreModeCertificate:
+			entry = &tlsStoreEntry{SecretName: *info.TLSSecretName}
+		case switchboard.TLSStoreModeDefault:
+			entry = &tlsStoreEntry{SecretName: *info.TLSSecretName, Default: true}
+		}
+	}
+
+	// ...and then update the shared TLS store, retrying if it was modified concurrently
+	key := types.NamespacedName{Name: tlsStoreName, Namespace: t.namespace}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		store := traefik.TLSStore{}
+		exists := true
+		if err := t.client.Get(ctx, key, &store); err != nil {
+			if !apierrs.IsNotFound(err) {
+				return err
+			}
+			// There is no need to create the TLS store if there is nothing to add
+			if entry == nil {
+				return nil
+			}
+			exists = false
+			store.ObjectMeta = metav1.ObjectMeta{
+				Name:      key.Name,
+				Namespace: key.Namespace,
+				Labels:    map[string]string{managedByLabelKey: "switchboard"},
+			}
+		}
+
+		entries, err := t.entries(&store)
+		if err != nil {
+			return err
+		}
+		updated := store.DeepCopy()
+		entries, err = t.reconcileSpec(&updated.Spec, entries, owner.GetName(), entry)
+		if err != nil {
+			return err
+		}
+		if err := t.setEntries(updated, entries); err != nil {
+			return err
+		}
+
+		// Remove the TLS store if it was created by Switchboard and is not required anymore
+		if exists && len(entries) == 0 &&
+			updated.Labels[managedByLabelKey] == "switchboard" &&
+			equality.Semantic.DeepEqual(updated.Spec, traefik.TLSStoreSpec{}) {
+			return deleteIfFound(ctx, t.client, updated)
+		}
+		if !exists {
+			return createObject(ctx, t.client, updated)
+		}
+		if equality.Semantic.DeepEqual(&store, updated) {
+			if entry != nil {
+				notifyUnchanged(ctx, updated)
+			}
+			return nil
+		}
+		return updateObject(ctx, t.client, &store, updated)
+	})
+}
+
+// updateOptions binds the owner to the TLS option profile with the given name (which may be nil)
+// and releases all other TLS options managed by Switchboard that the owner was bound to.
+func (t *tlsStore) updateOptions(
+	ctx context.Context, owner metav1.Object, name *string,
+) error {
+	if len(t.options) == 0 {
+		return nil
+	}
+
+	// First, we release the TLS options that the owner does not reference anymore
+	var list traefik.TLSOptionList
+	if err := t.client.List(ctx, &list,
+		client.InNamespace(owner.GetNamespace()),
+		client.MatchingLabels{managedByLabelKey: "switchboard"},
+	); err != nil {
+		return fmt.Errorf("failed to list TLS options: %w", err)
+	}
+	for _, option := range list.Items {
+		if name != nil && option.Name == *name {
+			continue
+		}
+		key := types.NamespacedName{Name: option.Name, Namespace: option.Namespace}
+		if err := t.updateOption(ctx, key, owner.GetName(), nil); err != nil {
+			return err
+		}
+	}
+
+	// Then, we bind the owner to the referenced TLS option
+	if name == nil {
+		return nil
+	}
+	key := types.NamespacedName{Name: *name, Namespace: owner.GetNamespace()}
+	spec := t.options[*name]
+	return t.updateOption(ctx, key, owner.GetName(), &spec)
+}
+
+// updateOption adds the route to the routes bound to the TLS option with the given key if the spec
+// is set and removes it otherwise. TLS options are created and deleted as required.
+func (t *tlsStore) updateOption(
+	ctx context.Context, key types.NamespacedName, route string, spec *traefik.TLSOptionSpec,
+) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		option := traefik.TLSOption{}
+		exists := true
+		if err := t.client.Get(ctx, key, &option); err != nil {
+			if !apierrs.IsNotFound(err) {
+				return err
+			}
+			if spec == nil {
+				return nil
+			}
+			exists = false
+			option.ObjectMeta = metav1.ObjectMeta{
+				Name:      key.Name,
+				Namespace: key.Namespace,
+				Labels:    map[string]string{managedByLabelKey: "switchboard"},
+			}
+		}
+		if option.Labels[managedByLabelKey] != "switchboard" {
+			if spec == nil {
+				return nil
+			}
+			return fmt.Errorf("TLS option %q is not managed by switchboard", key.Name)
+		}
+
+		routes, err := t.routes(&option)
+		if err != nil {
+			return err
+		}
+		routes = slices.DeleteFunc(routes, func(r string) bool { return r == route })
+		if spec != nil {
+			routes = append(routes, route)
+			slices.Sort(routes)
+		}
+		if len(routes) == 0 {
+			if exists {
+				return deleteIfFound(ctx, t.client, &option)
+			}
+			return nil
+		}
+
+		updated := option.DeepCopy()
+		if spec != nil {
+			updated.Spec = *spec.DeepCopy()
+		}
+		if err := t.setRoutes(updated, routes); err != nil {
+			return err
+		}
+		if !exists {
+			return createObject(ctx, t.client, updated)
+		}
+		if equality.Semantic.DeepEqual(&option, updated) {
+			if spec != nil {
+				notifyUnchanged(ctx, updated)
+			}
+			return nil
+		}
+		return updateObject(ctx, t.client, &option, updated)
+	})
+}
+
+//-------------------------------------------------------------------------------------------------
+// UTILS
+//-------------------------------------------------------------------------------------------------
+
+func (*tlsStore) entries(store *traefik.TLSStore) (map[string]tlsStoreEntry, error) {
+	entries := make(map[string]tlsStoreEntry)
+	annotation, ok := store.Annotations[tlsStoreEntriesAnnotationKey]
+	if !ok {
+		return entries, nil
+	}
+	if err := json.Unmarshal([]byte(annotation), &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse managed TLS store entries: %s", err)
+	}
+	return entries, nil
+}
+
+func (*tlsStore) setEntries(store *traefik.TLSStore, entries map[string]tlsStoreEntry) error {
+	annotations := defaultEmpty(store.GetAnnotations())
+	if len(entries) == 0 {
+		delete(annotations, tlsStoreEntriesAnnotationKey)
+	} else {
+		value, err := json.Marshal(entries)
+		if err != nil {
+			return fmt.Errorf("failed to serialize managed TLS store entries: %s", err)
+		}
+		annotations[tlsStoreEntriesAnnotationKey] = string(value)
+	}
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+	store.SetAnnotations(annotations)
+	return nil
+}
+
+// reconcileSpec updates the spec of a TLS store given the previously managed entries as well as
+// the entry for the given route (which may be nil). It returns the updated managed entries.
+func (*tlsStore) reconcileSpec(
+	spec *traefik.TLSStoreSpec,
+	entries map[string]tlsStoreEntry,
+	route string,
+	entry *tlsStoreEntry,
+) (map[string]tlsStoreEntry, error) {
+	// Find the secrets that are currently managed
+	managed := make(map[string]struct{})
+	var managedDefault *string
+	for _, e := range entries {
+		if e.Default {
+			managedDefault = &e.SecretName
+		} else {
+			managed[e.SecretName] = struct{}{}
+		}
+	}
+
+	// Update the entries
+	updated := maps.Clone(entries)
+	if entry == nil {
+		delete(updated, route)
+	} else {
+		updated[route] = *entry
+	}
+	routes := slices.Sorted(maps.Keys(updated))
+
+	// Compute the default certificate which must not override an unmanaged one
+	var defaultSecret *string
+	for _, r := range routes {
+		if !updated[r].Default {
+			continue
+		}
+		if defaultSecret != nil && *defaultSecret != updated[r].SecretName {
+			return nil, fmt.Errorf(
+				"default certificate of TLS store is already requested by another route",
+			)
+		}
+		secret := updated[r].SecretName
+		defaultSecret = &secret
+	}
+	isUnmanagedDefault := spec.DefaultCertificate != nil &&
+		(managedDefault == nil || *managedDefault != spec.DefaultCertificate.SecretName)
+	if isUnmanagedDefault {
+		if defaultSecret != nil && *defaultSecret != spec.DefaultCertificate.SecretName {
+			return nil, fmt.Errorf("default certificate of TLS store is not managed by switchboard")
+		}
+	} else if defaultSecret != nil {
+		spec.DefaultCertificate = &traefik.Certificate{SecretName: *defaultSecret}
+	} else {
+		spec.DefaultCertificate = nil
+	}
+
+	// Compute the certificates, keeping all unmanaged certificates
+	var certificates []traefik.Certificate
+	for _, certificate := range spec.Certificates {
+		if _, ok := managed[certificate.SecretName]; !ok {
+			certificates = append(certificates, certificate)
+		}
+	}
+	for _, r := range routes {
+		e := updated[r]
+		if e.Default || slices.Contains(certificates, traefik.Certificate{SecretName: e.SecretName}) {
+			continue
+		}
+		certificates = append(certificates, traefik.Certificate{SecretName: e.SecretName})
+	}
+	spec.Certificates = certificates
+	return updated, nil
+}
+
+func (*tlsStore) routes(option *traefik.TLSOption) ([]string, error) {
+	var routes []string
+	annotation, ok := option.Annotations[tlsOptionRoutesAnnotationKey]
+	if !ok {
+		return routes, nil
+	}
+	if err := json.Unmarshal([]byte(annotation), &routes); err != nil {
+		return nil, fmt.Errorf("failed to parse routes bound to TLS option: %s", err)
+	}
+	return routes, nil
+}
+
+func (*tlsStore) setRoutes(option *traefik.TLSOption, routes []string) error {
+	value, err := json.Marshal(routes)
+	if err != nil {
+		return fmt.Errorf("failed to serialize routes bound to TLS option: %s", err)
+	}
+	annotations := defaultEmpty(option.GetAnnotations())
+	annotations[tlsOptionRoutesAnnotationKey] = string(value)
+	option.SetAnnotations(annotations)
+	return nil
+}
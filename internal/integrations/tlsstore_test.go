@@ -0,0 +1,211 @@
+package integrations
+
+import (
+	"context"
+	"testing"
+
+	"github.com/borchero/switchboard/internal/k8tests"
+	"github.com/borchero/switchboard/internal/switchboard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	traefik "github.com/traefik/traefik/v3/pkg/provider/kubernetes/crd/traefikio/v1alpha1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestTLSStoreUpdateResource(t *testing.T) {
+	// Setup
+	ctx := context.Background()
+	scheme := k8tests.NewScheme()
+	client := k8tests.NewClient(t, scheme)
+	namespace, shutdown := k8tests.NewNamespace(ctx, t, client)
+	defer shutdown()
+
+	owner := k8tests.DummyService("my-service", namespace, 80)
+	err := client.Create(ctx, &owner)
+	require.Nil(t, err)
+	integration := NewTLSStore(client, namespace, switchboard.TLSStoreModeCertificate)
+	key := types.NamespacedName{Name: "default", Namespace: namespace}
+
+	// No TLS store should be created if no TLS secret is provided
+	info := IngressInfo{Hosts: []string{"example.com"}}
+	err = integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	var store traefik.TLSStore
+	err = client.Get(ctx, key, &store)
+	assert.True(t, apierrs.IsNotFound(err))
+
+	// The TLS store should be created with the TLS secret
+	tlsName := "test-tls"
+	info.TLSSecretName = &tlsName
+	err = integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	err = client.Get(ctx, key, &store)
+	require.Nil(t, err)
+	assert.Equal(t, []traefik.Certificate{{SecretName: tlsName}}, store.Spec.Certificates)
+
+	// The annotation allows to set the default certificate
+	owner.Annotations = map[string]string{"switchboard.borchero.com/tls-store": "default"}
+	err = integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	err = client.Get(ctx, key, &store)
+	require.Nil(t, err)
+	assert.Len(t, store.Spec.Certificates, 0)
+	assert.Equal(t, tlsName, store.Spec.DefaultCertificate.SecretName)
+
+	// When TLS is removed, the TLS store should be removed again
+	info.TLSSecretName = nil
+	err = integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	err = client.Get(ctx, key, &store)
+	assert.True(t, apierrs.IsNotFound(err))
+}
+
+func TestTLSStoreSelection(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClientBuilder().WithScheme(k8tests.NewScheme()).Build()
+	integration := NewTLSStore(client, "traefik", switchboard.TLSStoreModeCertificate)
+	key := types.NamespacedName{Name: "default", Namespace: "traefik"}
+	tlsName := "test-tls"
+	info := IngressInfo{Hosts: []string{"example.com"}, TLSSecretName: &tlsName}
+
+	// Ingress routes in other namespaces should not be registered...
+	owner := k8tests.DummyService("my-service", "default", 80)
+	err := integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	var store traefik.TLSStore
+	err = client.Get(ctx, key, &store)
+	assert.True(t, apierrs.IsNotFound(err))
+
+	// ...and fail if they explicitly request to be registered
+	owner.Annotations = map[string]string{"switchboard.borchero.com/tls-store": "default"}
+	err = integration.UpdateResource(ctx, &owner, info)
+	assert.ErrorContains(t, err, "namespace \"traefik\"")
+
+	// Ingress routes ignoring the cert-manager integration should not be registered
+	owner = k8tests.DummyService("my-service", "traefik", 80)
+	owner.Annotations = map[string]string{"switchboard.borchero.com/ignore": "cert-manager"}
+	err = integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	err = client.Get(ctx, key, &store)
+	assert.True(t, apierrs.IsNotFound(err))
+
+	// Otherwise, the TLS secret should be registered in the TLS store
+	owner.Annotations = nil
+	err = integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	err = client.Get(ctx, key, &store)
+	require.Nil(t, err)
+	assert.Equal(t, []traefik.Certificate{{SecretName: tlsName}}, store.Spec.Certificates)
+}
+
+func TestTLSStoreOptions(t *testing.T) {
+	ctx := context.Background()
+	unmanaged := &traefik.TLSOption{
+		ObjectMeta: metav1.ObjectMeta{Name: "custom", Namespace: "default"},
+	}
+	client := fake.NewClientBuilder().
+		WithScheme(k8tests.NewScheme()).
+		WithObjects(unmanaged).
+		Build()
+	profiles := map[string]traefik.TLSOptionSpec{
+		"modern": {MinVersion: "VersionTLS13"},
+		"custom": {MinVersion: "VersionTLS12"},
+	}
+	integration := NewTLSStore(
+		client, "traefik", switchboard.TLSStoreModeNone, WithTLSOptions(profiles),
+	)
+	key := types.NamespacedName{Name: "modern", Namespace: "default"}
+	tlsName := "test-tls"
+	modern := "modern"
+	info := IngressInfo{
+		Hosts: []string{"example.com"}, TLSSecretName: &tlsName, TLSOptionName: &modern,
+	}
+
+	// Ingress routes referencing a profile should be bound to a TLS option
+	first := k8tests.DummyService("first", "default", 80)
+	second := k8tests.DummyService("second", "default", 80)
+	err := integration.UpdateResource(ctx, &first, info)
+	require.Nil(t, err)
+	err = integration.UpdateResource(ctx, &second, info)
+	require.Nil(t, err)
+	var option traefik.TLSOption
+	err = client.Get(ctx, key, &option)
+	require.Nil(t, err)
+	assert.Equal(t, profiles["modern"], option.Spec)
+	assert.Equal(t, `["first","second"]`,
+		option.Annotations["switchboard.borchero.com/tls-option-routes"])
+
+	// The TLS option should be kept as long as it is referenced
+	err = integration.UpdateResource(ctx, &first, IngressInfo{})
+	require.Nil(t, err)
+	err = client.Get(ctx, key, &option)
+	require.Nil(t, err)
+	assert.Equal(t, `["second"]`, option.Annotations["switchboard.borchero.com/tls-option-routes"])
+
+	// Unmanaged TLS options must not be modified
+	custom := "custom"
+	info.TLSOptionName = &custom
+	err = integration.UpdateResource(ctx, &second, info)
+	assert.ErrorContains(t, err, "not managed by switchboard")
+
+	// Once no ingress route references the TLS option anymore, it should be removed
+	info.TLSOptionName = nil
+	err = integration.UpdateResource(ctx, &second, info)
+	require.Nil(t, err)
+	err = client.Get(ctx, key, &option)
+	assert.True(t, apierrs.IsNotFound(err))
+	err = client.Get(ctx, types.NamespacedName{Name: "custom", Namespace: "default"}, &option)
+	require.Nil(t, err)
+	assert.Equal(t, traefik.TLSOptionSpec{}, option.Spec)
+}
+
+func TestTLSStoreReconcileSpec(t *testing.T) {
+	var integration tlsStore
+
+	// Unmanaged certificates are kept
+	spec := traefik.TLSStoreSpec{Certificates: []traefik.Certificate{{SecretName: "manual"}}}
+	entries, err := integration.reconcileSpec(
+		&spec, map[string]tlsStoreEntry{}, "a", &tlsStoreEntry{SecretName: "a-tls"},
+	)
+	require.Nil(t, err)
+	assert.Equal(t, []traefik.Certificate{{SecretName: "manual"}, {SecretName: "a-tls"}},
+		spec.Certificates)
+
+	// Default certificates are set and replaced
+	entries, err = integration.reconcileSpec(
+		&spec, entries, "b", &tlsStoreEntry{SecretName: "b-tls", Default: true},
+	)
+	require.Nil(t, err)
+	assert.Equal(t, "b-tls", spec.DefaultCertificate.SecretName)
+
+	entries, err = integration.reconcileSpec(
+		&spec, entries, "b", &tlsStoreEntry{SecretName: "b-tls-new", Default: true},
+	)
+	require.Nil(t, err)
+	assert.Equal(t, "b-tls-new", spec.DefaultCertificate.SecretName)
+
+	// Only a single route may request the default certificate
+	_, err = integration.reconcileSpec(
+		&spec, entries, "c", &tlsStoreEntry{SecretName: "c-tls", Default: true},
+	)
+	assert.NotNil(t, err)
+
+	// Removing entries removes the managed certificates only
+	entries, err = integration.reconcileSpec(&spec, entries, "a", nil)
+	require.Nil(t, err)
+	entries, err = integration.reconcileSpec(&spec, entries, "b", nil)
+	require.Nil(t, err)
+	assert.Len(t, entries, 0)
+	assert.Nil(t, spec.DefaultCertificate)
+	assert.Equal(t, []traefik.Certificate{{SecretName: "manual"}}, spec.Certificates)
+
+	// Unmanaged default certificates must not be overridden
+	spec = traefik.TLSStoreSpec{DefaultCertificate: &traefik.Certificate{SecretName: "manual"}}
+	_, err = integration.reconcileSpec(
+		&spec, map[string]tlsStoreEntry{}, "a", &tlsStoreEntry{SecretName: "a-tls", Default: true},
+	)
+	assert.NotNil(t, err)
+}
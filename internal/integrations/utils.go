@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"dario.cat/mergo"
+	"github.com/asaskevich/govalidator"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -49,3 +50,15 @@ func defaultEmpty(m map[string]string) map[string]string {
 	}
 	return m
 }
+
+// targetRecordType returns the type of the DNS record that is required to point to the given
+// target.
+func targetRecordType(target string) string {
+	if govalidator.IsIPv4(target) {
+		return "A"
+	}
+	if govalidator.IsIPv6(target) {
+		return "AAAA"
+	}
+	return "CNAME"
+}
@@ -0,0 +1,305 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/borchero/switchboard/internal/k8s"
+	"github.com/borchero/switchboard/internal/switchboard"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// WebhookSignatureHeader is the header which contains the HMAC-SHA256 signature of the payload.
+	WebhookSignatureHeader = "X-Switchboard-Signature"
+
+	webhookDefaultRetries = 3
+	webhookDefaultBackoff = 250 * time.Millisecond
+	webhookTimeout        = 5 * time.Second
+)
+
+// WebhookEvent describes the kind of change that a webhook payload notifies about.
+type WebhookEvent string
+
+const (
+	// WebhookEventUpsert notifies about a created or updated ingress.
+	WebhookEventUpsert WebhookEvent = "upsert"
+	// WebhookEventDelete notifies about a deleted ingress (or an ingress without hosts).
+	WebhookEventDelete WebhookEvent = "delete"
+)
+
+// WebhookPayload is the JSON payload that is sent to the webhook.
+type WebhookPayload struct {
+	Event         WebhookEvent `json:"event"`
+	Namespace     string       `json:"namespace"`
+	Name          string       `json:"name"`
+	Hosts         []string     `json:"hosts,omitempty"`
+	TLSSecretName string       `json:"tlsSecretName,omitempty"`
+	Targets       []string     `json:"targets,omitempty"`
+}
+
+// WebhookOption allows to customize the webhook integration.
+type WebhookOption func(*webhook)
+
+// WithWebhookRetries sets the maximum number of retries for failed deliveries along with the
+// initial backoff which is doubled after every attempt. By default, deliveries are retried three
+// times with an initial backoff of 250ms. A zero backoff keeps the default.
+func WithWebhookRetries(maxRetries int, backoff time.Duration) WebhookOption {
+	return func(w *webhook) {
+		w.maxRetries = maxRetries
+		if backoff > 0 {
+			w.backoff = backoff
+		}
+	}
+}
+
+type webhook struct {
+	client     client.Client
+	target     switchboard.Target
+	url        string
+	secret     []byte
+	state      types.NamespacedName
+	httpClient *http.Client
+	maxRetries int
+	backoff    time.Duration
+	timeout    time.Duration
+}
+
+// NewWebhook initializes a new integration which notifies the given URL about every ingress via
+// JSON payloads sent with HTTP POST requests. If a secret is provided, payloads are signed with
+// HMAC-SHA256 and the hex-encoded signature is sent via the `X-Switchboard-Signature` header. If a
+// target is provided, the targets are included in the payload.
+//
+// The hashes of the payloads that were delivered last are stored in the provided config map such
+// that unchanged ingresses are not sent again. As deliveries block the reconciliation of the
+// ingress, all attempts to deliver a payload are limited to five seconds in total. Afterwards, the
+// delivery fails and the ingress is reconciled again later.
+func NewWebhook(
+	client client.Client,
+	target switchboard.Target,
+	url string,
+	secret []byte,
+	state types.NamespacedName,
+	options ...WebhookOption,
+) Integration {
+	integration := &webhook{
+		client:     client,
+		target:     target,
+		url:        url,
+		secret:     secret,
+		state:      state,
+		httpClient: &http.Client{},
+		maxRetries: webhookDefaultRetries,
+		backoff:    webhookDefaultBackoff,
+		timeout:    webhookTimeout,
+	}
+	for _, option := range options {
+		option(integration)
+	}
+	return integration
+}
+
+func (*webhook) Name() string {
+	return "webhook"
+}
+
+func (*webhook) OwnedResource() client.Object {
+	return nil
+}
+
+func (w *webhook) Watches() []k8s.Watch {
+	if w.target == nil {
+		return nil
+	}
+	return w.target.Watches()
+}
+
+func (w *webhook) UpdateResource(
+	ctx context.Context, owner metav1.Object, info IngressInfo,
+) error {
+	// First, we build the payload...
+	payload := WebhookPayload{
+		Event:     WebhookEventDelete,
+		Namespace: owner.GetNamespace(),
+		Name:      owner.GetName(),
+	}
+	if len(info.Hosts) > 0 {
+		payload.Event = WebhookEventUpsert
+		payload.Hosts = slices.Sorted(slices.Values(info.Hosts))
+		if info.TLSSecretName != nil {
+			payload.TLSSecretName = *info.TLSSecretName
+		}
+		if w.target != nil {
+			targets, err := w.target.Targets(ctx, w.client)
+			if err != nil {
+				return fmt.Errorf("failed to query targets: %w", err)
+			}
+			payload.Targets = targets
+		}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to serialize payload: %s", err)
+	}
+
+	// ...and check whether it needs to be delivered
+	key := w.stateKey(owner)
+	hashes, err := w.hashes(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read webhook state: %w", err)
+	}
+	hash := w.hash(body)
+	previous, delivered := hashes[key]
+	if payload.Event == WebhookEventDelete && !delivered {
+		// No need to notify about deletion if the ingress was never delivered
+		return nil
+	}
+	if previous == hash {
+		return nil
+	}
+	if isDryRun(ctx) {
+		return nil
+	}
+
+	// Then, we can deliver the payload and store the hash
+	if err := w.send(ctx, body); err != nil {
+		return fmt.Errorf("failed to deliver webhook: %w", err)
+	}
+	if payload.Event == WebhookEventDelete {
+		hash = ""
+	}
+	if err := w.storeHash(ctx, key, hash); err != nil {
+		return fmt.Errorf("failed to update webhook state: %w", err)
+	}
+	return nil
+}
+
+//-------------------------------------------------------------------------------------------------
+// UTILS
+//-------------------------------------------------------------------------------------------------
+
+func (*webhook) stateKey(owner metav1.Object) string {
+	// Namespaces cannot contain underscores such that the key is unique
+	return fmt.Sprintf("%s_%s", owner.GetNamespace(), owner.GetName())
+}
+
+func (*webhook) hash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+func (w *webhook) signature(body []byte) string {
+	mac := hmac.New(sha256.New, w.secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (w *webhook) send(ctx context.Context, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+
+	backoff := w.backoff
+	var err error
+	for attempt := 0; attempt <= w.maxRetries; attempt++ {
+		if attempt > 0 {
+			// There is no point in waiting if the next attempt would exceed the timeout
+			if deadline, _ := ctx.Deadline(); time.Until(deadline) < backoff {
+				return err
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		var retryable bool
+		retryable, err = w.deliver(ctx, body)
+		if err == nil || !retryable {
+			return err
+		}
+	}
+	return err
+}
+
+func (w *webhook) deliver(ctx context.Context, body []byte) (bool, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if len(w.secret) > 0 {
+		request.Header.Set(WebhookSignatureHeader, w.signature(body))
+	}
+
+	response, err := w.httpClient.Do(request)
+	if err != nil {
+		return true, err
+	}
+	defer response.Body.Close() // nolint:errcheck
+
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return false, nil
+	}
+	err = fmt.Errorf("webhook responded with status %d", response.StatusCode)
+	// Client errors (except for rate limiting) are not retried
+	retryable := response.StatusCode >= 500 || response.StatusCode == http.StatusTooManyRequests
+	return retryable, err
+}
+
+func (w *webhook) hashes(ctx context.Context) (map[string]string, error) {
+	var configMap v1.ConfigMap
+	if err := w.client.Get(ctx, w.state, &configMap); err != nil {
+		if apierrs.IsNotFound(err) {
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+	return defaultEmpty(configMap.Data), nil
+}
+
+func (w *webhook) storeHash(ctx context.Context, key string, hash string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap := v1.ConfigMap{}
+		exists := true
+		if err := w.client.Get(ctx, w.state, &configMap); err != nil {
+			if !apierrs.IsNotFound(err) {
+				return err
+			}
+			exists = false
+			configMap.ObjectMeta = metav1.ObjectMeta{
+				Name:      w.state.Name,
+				Namespace: w.state.Namespace,
+				Labels:    map[string]string{managedByLabelKey: "switchboard"},
+			}
+		}
+
+		data := maps.Clone(defaultEmpty(configMap.Data))
+		if hash == "" {
+			delete(data, key)
+		} else {
+			data[key] = hash
+		}
+		configMap.Data = data
+
+		if !exists {
+			return w.client.Create(ctx, &configMap)
+		}
+		return w.client.Update(ctx, &configMap)
+	})
+}
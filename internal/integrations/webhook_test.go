@@ -0,0 +1,196 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/borchero/switchboard/internal/k8tests"
+	"github.com/borchero/switchboard/internal/switchboard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestWebhookUpdateResource(t *testing.T) {
+	// Setup
+	ctx := context.Background()
+	scheme := k8tests.NewScheme()
+	client := k8tests.NewClient(t, scheme)
+	namespace, shutdown := k8tests.NewNamespace(ctx, t, client)
+	defer shutdown()
+
+	server := newTestWebhookServer(t)
+	owner := k8tests.DummyService("my-service", namespace, 80)
+	state := types.NamespacedName{Name: "switchboard-webhook", Namespace: namespace}
+	integration := NewWebhook(
+		client, switchboard.NewStaticTarget("127.0.0.1"), server.URL, nil, state,
+	)
+
+	// Nothing should be sent for ingresses that were never delivered
+	var info IngressInfo
+	err := integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	assert.Len(t, server.payloads(), 0)
+
+	// The ingress should be delivered once
+	tlsName := "my-tls"
+	info = IngressInfo{Hosts: []string{"example.com"}, TLSSecretName: &tlsName}
+	err = integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	err = integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	payloads := server.payloads()
+	require.Len(t, payloads, 1)
+	assert.Equal(t, WebhookPayload{
+		Event:         WebhookEventUpsert,
+		Namespace:     namespace,
+		Name:          "my-service",
+		Hosts:         []string{"example.com"},
+		TLSSecretName: "my-tls",
+		Targets:       []string{"127.0.0.1"},
+	}, payloads[0])
+
+	// Changes should not be delivered in dry-run mode
+	info.Hosts = []string{"www.example.com", "example.com"}
+	err = integration.UpdateResource(WithDryRun(ctx), &owner, info)
+	require.Nil(t, err)
+	assert.Len(t, server.payloads(), 1)
+
+	// Changes should be delivered otherwise
+	err = integration.UpdateResource(ctx, &owner, info)
+	require.Nil(t, err)
+	payloads = server.payloads()
+	require.Len(t, payloads, 2)
+	assert.Equal(t, []string{"example.com", "www.example.com"}, payloads[1].Hosts)
+
+	// Deletion should be delivered once
+	err = integration.UpdateResource(ctx, &owner, IngressInfo{})
+	require.Nil(t, err)
+	err = integration.UpdateResource(ctx, &owner, IngressInfo{})
+	require.Nil(t, err)
+	payloads = server.payloads()
+	require.Len(t, payloads, 3)
+	assert.Equal(t, WebhookEventDelete, payloads[2].Event)
+}
+
+func TestWebhookSend(t *testing.T) {
+	ctx := context.Background()
+	server := newTestWebhookServer(t)
+	integration := NewWebhook(
+		nil, nil, server.URL, []byte("secret"), types.NamespacedName{},
+		WithWebhookRetries(2, time.Millisecond),
+	).(*webhook)
+	body := []byte(`{"event":"upsert"}`)
+
+	// Payloads should be signed
+	err := integration.send(ctx, body)
+	require.Nil(t, err)
+	assert.Equal(t, integration.signature(body), server.lastSignature())
+	assert.NotEqual(t, integration.signature(body), integration.signature([]byte("{}")))
+
+	// Server errors should be retried
+	server.fail(2, http.StatusServiceUnavailable)
+	err = integration.send(ctx, body)
+	require.Nil(t, err)
+	assert.Equal(t, 4, server.requests())
+
+	// Retries should be exhausted eventually
+	server.fail(3, http.StatusServiceUnavailable)
+	err = integration.send(ctx, body)
+	assert.NotNil(t, err)
+	assert.Equal(t, 7, server.requests())
+
+	// Client errors should not be retried
+	server.fail(1, http.StatusBadRequest)
+	err = integration.send(ctx, body)
+	assert.NotNil(t, err)
+	assert.Equal(t, 8, server.requests())
+
+	// Retries must not exceed the total timeout
+	integration.maxRetries = 10
+	integration.backoff = 20 * time.Millisecond
+	integration.timeout = 100 * time.Millisecond
+	server.fail(11, http.StatusServiceUnavailable)
+	start := time.Now()
+	err = integration.send(ctx, body)
+	assert.ErrorContains(t, err, "status 503")
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+	assert.Equal(t, 11, server.requests())
+}
+
+//-------------------------------------------------------------------------------------------------
+// UTILS
+//-------------------------------------------------------------------------------------------------
+
+type testWebhookServer struct {
+	*httptest.Server
+	mutex      sync.Mutex
+	bodies     [][]byte
+	signature  string
+	count      int
+	failures   int
+	failStatus int
+}
+
+func newTestWebhookServer(t *testing.T) *testWebhookServer {
+	server := &testWebhookServer{}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.handle))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (s *testWebhookServer) handle(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.count++
+	if s.failures > 0 {
+		s.failures--
+		w.WriteHeader(s.failStatus)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	s.bodies = append(s.bodies, body)
+	s.signature = r.Header.Get(WebhookSignatureHeader)
+}
+
+func (s *testWebhookServer) fail(count int, status int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.failures = count
+	s.failStatus = status
+}
+
+func (s *testWebhookServer) requests() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.count
+}
+
+func (s *testWebhookServer) lastSignature() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.signature
+}
+
+func (s *testWebhookServer) payloads() []WebhookPayload {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	result := make([]WebhookPayload, 0, len(s.bodies))
+	for _, body := range s.bodies {
+		var payload WebhookPayload
+		if err := json.Unmarshal(body, &payload); err == nil {
+			result = append(result, payload)
+		}
+	}
+	return result
+}
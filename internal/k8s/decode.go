@@ -0,0 +1,74 @@
+package k8s
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DecodeOption customizes the decoding of objects.
+type DecodeOption func(*decodeOptions)
+
+type decodeOptions struct {
+	skipUnknownKinds bool
+}
+
+// WithSkipUnknownKinds causes documents whose kind is not known to the scheme to be skipped
+// instead of resulting in an error.
+func WithSkipUnknownKinds() DecodeOption {
+	return func(o *decodeOptions) {
+		o.skipUnknownKinds = true
+	}
+}
+
+// DecodeObjects reads all YAML or JSON documents from the given reader and converts them into
+// typed objects of the given scheme. Empty documents are skipped and unknown kinds result in an
+// error unless configured otherwise.
+func DecodeObjects(
+	reader io.Reader, scheme *runtime.Scheme, options ...DecodeOption,
+) ([]client.Object, error) {
+	var opts decodeOptions
+	for _, option := range options {
+		option(&opts)
+	}
+	decoder := yaml.NewYAMLOrJSONDecoder(reader, 4096)
+	var result []client.Object
+	for {
+		var u unstructured.Unstructured
+		if err := decoder.Decode(&u.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				return result, nil
+			}
+			return nil, fmt.Errorf("failed to parse document: %w", err)
+		}
+		if len(u.Object) == 0 {
+			continue
+		}
+
+		gvk := u.GroupVersionKind()
+		obj, err := scheme.New(gvk)
+		if err != nil && opts.skipUnknownKinds && runtime.IsNotRegisteredError(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unsupported kind %q: %w", gvk.String(), err)
+		}
+		if _, ok := obj.(*unstructured.Unstructured); ok {
+			obj = &u
+		} else if err := runtime.DefaultUnstructuredConverter.FromUnstructured(
+			u.Object, obj,
+		); err != nil {
+			return nil, fmt.Errorf("failed to convert %s %q: %w", gvk.Kind, u.GetName(), err)
+		}
+		typed, ok := obj.(client.Object)
+		if !ok {
+			return nil, fmt.Errorf("kind %q is not an object", gvk.String())
+		}
+		result = append(result, typed)
+	}
+}
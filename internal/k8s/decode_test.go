@@ -0,0 +1,51 @@
+package k8s
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+func TestDecodeObjects(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.Nil(t, clientgoscheme.AddToScheme(scheme))
+
+	// Multiple documents should be decoded into typed objects
+	objects, err := DecodeObjects(strings.NewReader(`
+apiVersion: v1
+kind: Service
+metadata:
+  name: traefik
+---
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-config
+data:
+  key: value
+`), scheme)
+	require.Nil(t, err)
+	require.Len(t, objects, 2)
+	assert.Equal(t, "traefik", objects[0].(*v1.Service).Name)
+	assert.Equal(t, map[string]string{"key": "value"}, objects[1].(*v1.ConfigMap).Data)
+
+	// Unknown kinds should be rejected unless they are skipped
+	unknown := `
+apiVersion: example.com/v1
+kind: Unknown
+metadata:
+  name: unknown
+`
+	_, err = DecodeObjects(strings.NewReader(unknown), scheme)
+	require.NotNil(t, err)
+
+	objects, err = DecodeObjects(strings.NewReader(unknown), scheme, WithSkipUnknownKinds())
+	require.Nil(t, err)
+	assert.Len(t, objects, 0)
+}
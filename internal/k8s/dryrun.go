@@ -0,0 +1,80 @@
+package k8s
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type dryRunClient struct {
+	client.Client
+}
+
+// NewDryRunClient wraps the given client such that all reads are passed through while writes are
+// never sent to the API server. Writes succeed as if they were applied, except for deletions of
+// resources which do not exist.
+func NewDryRunClient(c client.Client) client.Client {
+	return &dryRunClient{Client: c}
+}
+
+func (*dryRunClient) Create(context.Context, client.Object, ...client.CreateOption) error {
+	return nil
+}
+
+func (*dryRunClient) Update(context.Context, client.Object, ...client.UpdateOption) error {
+	return nil
+}
+
+func (*dryRunClient) Patch(
+	context.Context, client.Object, client.Patch, ...client.PatchOption,
+) error {
+	return nil
+}
+
+func (c *dryRunClient) Delete(
+	ctx context.Context, obj client.Object, _ ...client.DeleteOption,
+) error {
+	// Deletions must fail for missing resources such that they are not reported as deleted
+	existing := obj.DeepCopyObject().(client.Object)
+	return c.Get(ctx, client.ObjectKeyFromObject(obj), existing)
+}
+
+func (*dryRunClient) DeleteAllOf(
+	context.Context, client.Object, ...client.DeleteAllOfOption,
+) error {
+	return nil
+}
+
+func (c *dryRunClient) Status() client.SubResourceWriter {
+	return c.SubResource("status")
+}
+
+func (c *dryRunClient) SubResource(subResource string) client.SubResourceClient {
+	return &dryRunSubResourceClient{SubResourceClient: c.Client.SubResource(subResource)}
+}
+
+//-------------------------------------------------------------------------------------------------
+// SUBRESOURCES
+//-------------------------------------------------------------------------------------------------
+
+type dryRunSubResourceClient struct {
+	client.SubResourceClient
+}
+
+func (*dryRunSubResourceClient) Create(
+	context.Context, client.Object, client.Object, ...client.SubResourceCreateOption,
+) error {
+	return nil
+}
+
+func (*dryRunSubResourceClient) Update(
+	context.Context, client.Object, ...client.SubResourceUpdateOption,
+) error {
+	return nil
+}
+
+func (*dryRunSubResourceClient) Patch(
+	context.Context, client.Object, client.Patch, ...client.SubResourcePatchOption,
+) error {
+	return nil
+}
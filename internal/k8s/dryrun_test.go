@@ -0,0 +1,47 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestDryRunClient(t *testing.T) {
+	// Setup
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.Nil(t, clientgoscheme.AddToScheme(scheme))
+	existing := &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "existing", Namespace: "ns"}}
+	live := fake.NewClientBuilder().WithScheme(scheme).WithObjects(existing).Build()
+	c := NewDryRunClient(live)
+
+	// Creations should not be persisted
+	created := &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "created", Namespace: "ns"}}
+	require.Nil(t, c.Create(ctx, created))
+	err := live.Get(ctx, client.ObjectKeyFromObject(created), &v1.ConfigMap{})
+	assert.True(t, apierrs.IsNotFound(err))
+
+	// Updates should not be persisted
+	updated := existing.DeepCopy()
+	updated.Data = map[string]string{"key": "value"}
+	require.Nil(t, c.Update(ctx, updated))
+	require.Nil(t, c.Patch(ctx, updated, client.MergeFrom(existing)))
+	var current v1.ConfigMap
+	require.Nil(t, live.Get(ctx, client.ObjectKeyFromObject(existing), &current))
+	assert.Empty(t, current.Data)
+
+	// Deletions should not be persisted but fail for missing resources
+	require.Nil(t, c.Delete(ctx, existing))
+	require.Nil(t, live.Get(ctx, client.ObjectKeyFromObject(existing), &current))
+	err = c.Delete(ctx, created)
+	assert.True(t, apierrs.IsNotFound(err))
+}
@@ -0,0 +1,35 @@
+package k8s
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Watch describes a set of Kubernetes objects of a single type whose changes require the
+// reconciliation of dependent resources.
+type Watch struct {
+	// Object is an object of the watched type. Its name and namespace are ignored.
+	Object client.Object
+	// Predicates filter the events for objects of the watched type. Only events that pass all
+	// predicates trigger reconciliations.
+	Predicates []predicate.Predicate
+	// Map optionally maps a changed object to the requests of the dependent resources that need to
+	// be reconciled. If nil, all dependent resources are reconciled.
+	Map func(context.Context, client.Object) ([]reconcile.Request, error)
+}
+
+// WatchObject returns a watch for the given object only, identified by its name and namespace.
+func WatchObject(obj client.Object) Watch {
+	return Watch{
+		Object: obj,
+		Predicates: []predicate.Predicate{
+			predicate.NewPredicateFuncs(func(other client.Object) bool {
+				return obj.GetName() == other.GetName() &&
+					obj.GetNamespace() == other.GetNamespace()
+			}),
+		},
+	}
+}
@@ -0,0 +1,28 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/borchero/switchboard/internal/k8tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestWatchObject(t *testing.T) {
+	service := k8tests.DummyService("my-service", "my-namespace", 80)
+	watch := WatchObject(&service)
+	require.Len(t, watch.Predicates, 1)
+
+	// Only the watched object should pass the predicate
+	other := k8tests.DummyService("my-service", "my-namespace", 8080)
+	assert.True(t, watch.Predicates[0].Update(event.UpdateEvent{
+		ObjectOld: &service, ObjectNew: &other,
+	}))
+
+	other = k8tests.DummyService("your-service", "my-namespace", 80)
+	assert.False(t, watch.Predicates[0].Create(event.CreateEvent{Object: &other}))
+
+	other = k8tests.DummyService("my-service", "your-namespace", 80)
+	assert.False(t, watch.Predicates[0].Delete(event.DeleteEvent{Object: &other}))
+}
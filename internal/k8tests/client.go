@@ -5,7 +5,9 @@ import (
 	"path/filepath"
 	"testing"
 
+	switchboardv1alpha1 "github.com/borchero/switchboard/internal/api/v1alpha1"
 	certmanager "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	"github.com/stretchr/testify/require"
 	traefik "github.com/traefik/traefik/v3/pkg/provider/kubernetes/crd/traefikio/v1alpha1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -28,6 +30,10 @@ func NewScheme() *runtime.Scheme {
 	utilruntime.Must(traefik.AddToScheme(scheme))
 	// >>> external-dns
 	utilruntime.Must(externaldnsv1alpha1.AddToScheme(scheme))
+	// >>> prometheus-operator
+	utilruntime.Must(monitoringv1.AddToScheme(scheme))
+	// >>> switchboard
+	utilruntime.Must(switchboardv1alpha1.AddToScheme(scheme))
 	return scheme
 }
 
@@ -0,0 +1,212 @@
+package lint
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/borchero/switchboard/internal/switchboard"
+	muxer "github.com/traefik/traefik/v3/pkg/muxer/http"
+	traefik "github.com/traefik/traefik/v3/pkg/provider/kubernetes/crd/traefikio/v1alpha1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Severity describes how severe a finding is.
+type Severity string
+
+const (
+	// SeverityError indicates that Switchboard cannot process an ingress route as intended.
+	SeverityError Severity = "error"
+	// SeverityWarning indicates that an ingress route is likely misconfigured.
+	SeverityWarning Severity = "warning"
+)
+
+// Rules that findings are reported for.
+const (
+	RuleInvalidRule        = "invalid-rule"
+	RuleInvalidHostname    = "invalid-hostname"
+	RuleHostConflict       = "host-conflict"
+	RuleIgnoredRoute       = "ignored-route"
+	RuleUnknownIntegration = "unknown-integration"
+	RuleTLSSecretCollision = "tls-secret-collision"
+)
+
+const certManagerIntegration = "cert-manager"
+
+// Route is an ingress route along with the file that it was read from.
+type Route struct {
+	File  string
+	Route *traefik.IngressRoute
+}
+
+// Finding is a single problem with an ingress route.
+type Finding struct {
+	Rule      string   `json:"rule"`
+	Severity  Severity `json:"severity"`
+	File      string   `json:"file"`
+	Namespace string   `json:"namespace"`
+	Name      string   `json:"name"`
+	Message   string   `json:"message"`
+}
+
+// Options configure the checks of the linter.
+type Options struct {
+	// Selector is the selector of the Switchboard controller.
+	Selector switchboard.Selector
+	// Integrations are the names of all enabled integrations.
+	Integrations []string
+}
+
+// Lint checks the given ingress routes and returns all findings. Findings for individual routes are
+// reported in the order of the routes, followed by conflicts between routes.
+func Lint(routes []Route, options Options) []Finding {
+	var findings []Finding
+	hostOwners := map[string][]Route{}
+	secretOwners := map[string][]Route{}
+	for _, route := range routes {
+		report := func(rule string, severity Severity, message string, args ...any) {
+			findings = append(findings, finding(route, rule, severity, message, args...))
+		}
+
+		// First, we check whether the route is processed at all
+		annotations := route.Route.Annotations
+		if !options.Selector.Matches(annotations) {
+			if !explicitlyIgnored(annotations) {
+				report(RuleIgnoredRoute, SeverityWarning,
+					"Ingress route is ignored as its ingress class does not match the selector",
+				)
+			}
+			continue
+		}
+		for _, name := range options.Selector.IgnoredIntegrations(annotations) {
+			if !slices.Contains(options.Integrations, name) {
+				report(RuleUnknownIntegration, SeverityWarning,
+					"Ignore annotation references integration %q which is not enabled", name,
+				)
+			}
+		}
+
+		// Then, we check the rules and hosts of the route
+		for _, r := range route.Route.Spec.Routes {
+			if r.Kind != "Rule" {
+				continue
+			}
+			if _, err := muxer.ParseDomains(r.Match); err != nil {
+				report(RuleInvalidRule, SeverityError, "Rule %q cannot be parsed: %s", r.Match, err)
+			}
+		}
+		collection, err := switchboard.NewHostCollection().
+			WithTLSHostsIfAvailable(route.Route.Spec.TLS).
+			WithRouteHostsIfRequired(validRoutes(route.Route.Spec.Routes))
+		if err != nil {
+			// Unparseable rules are filtered above
+			continue
+		}
+		hosts := collection.Hosts()
+		slices.Sort(hosts)
+		for _, host := range hosts {
+			if problems := validateHostname(host); len(problems) > 0 {
+				report(RuleInvalidHostname, SeverityError,
+					"Host %q is invalid: %s", host, strings.Join(problems, "; "),
+				)
+				continue
+			}
+			hostOwners[host] = append(hostOwners[host], route)
+		}
+		// TLS secrets can only collide if cert-manager issues certificates into them
+		tls := route.Route.Spec.TLS
+		if tls != nil && tls.SecretName != "" && len(hosts) > 0 &&
+			slices.Contains(options.Integrations, certManagerIntegration) &&
+			options.Selector.MatchesIntegration(annotations, certManagerIntegration) {
+			key := route.Route.Namespace + "/" + tls.SecretName
+			secretOwners[key] = append(secretOwners[key], route)
+		}
+	}
+
+	// Eventually, we check for conflicts between routes
+	for _, host := range sortedKeys(hostOwners) {
+		for _, route := range hostOwners[host] {
+			if others := otherRoutes(hostOwners[host], route); len(others) > 0 {
+				findings = append(findings, finding(route, RuleHostConflict, SeverityWarning,
+					"Host %q is also used by %s", host, strings.Join(others, ", "),
+				))
+			}
+		}
+	}
+	for _, key := range sortedKeys(secretOwners) {
+		for _, route := range secretOwners[key] {
+			if others := otherRoutes(secretOwners[key], route); len(others) > 0 {
+				findings = append(findings, finding(route, RuleTLSSecretCollision, SeverityError,
+					"TLS secret %q is also used by %s",
+					route.Route.Spec.TLS.SecretName, strings.Join(others, ", "),
+				))
+			}
+		}
+	}
+	return findings
+}
+
+// HasErrors returns whether any of the given findings is an error.
+func HasErrors(findings []Finding) bool {
+	return slices.ContainsFunc(findings, func(f Finding) bool {
+		return f.Severity == SeverityError
+	})
+}
+
+//-------------------------------------------------------------------------------------------------
+// UTILS
+//-------------------------------------------------------------------------------------------------
+
+func finding(
+	route Route, rule string, severity Severity, message string, args ...any,
+) Finding {
+	return Finding{
+		Rule:      rule,
+		Severity:  severity,
+		File:      route.File,
+		Namespace: route.Route.Namespace,
+		Name:      route.Route.Name,
+		Message:   fmt.Sprintf(message, args...),
+	}
+}
+
+func explicitlyIgnored(annotations map[string]string) bool {
+	// A route is explicitly ignored if it is not matched even when disregarding the ingress class
+	return !switchboard.NewSelector(nil).Matches(annotations)
+}
+
+func validRoutes(routes []traefik.Route) []traefik.Route {
+	return slices.DeleteFunc(slices.Clone(routes), func(r traefik.Route) bool {
+		if r.Kind != "Rule" {
+			return false
+		}
+		_, err := muxer.ParseDomains(r.Match)
+		return err != nil
+	})
+}
+
+func validateHostname(host string) []string {
+	if strings.HasPrefix(host, "*.") {
+		return validation.IsWildcardDNS1123Subdomain(host)
+	}
+	return validation.IsDNS1123Subdomain(host)
+}
+
+func otherRoutes(owners []Route, route Route) []string {
+	var result []string
+	for _, owner := range owners {
+		if owner.Route != route.Route {
+			result = append(result, owner.Route.Namespace+"/"+owner.Route.Name)
+		}
+	}
+	return result
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
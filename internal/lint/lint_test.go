@@ -0,0 +1,105 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/borchero/switchboard/internal/switchboard"
+	"github.com/stretchr/testify/assert"
+	traefik "github.com/traefik/traefik/v3/pkg/provider/kubernetes/crd/traefikio/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLint(t *testing.T) {
+	ingressClass := "traefik"
+	options := Options{
+		Selector:     switchboard.NewSelector(&ingressClass),
+		Integrations: []string{"external-dns", "cert-manager"},
+	}
+	annotations := map[string]string{"kubernetes.io/ingress.class": "traefik"}
+
+	// Valid routes should not result in findings
+	routes := []Route{
+		newRoute("a.yaml", "a", annotations, "www-tls", "Host(`www.example.com`)"),
+		newRoute("a.yaml", "b", annotations, "", "Host(`*.example.com`)"),
+	}
+	assert.Empty(t, Lint(routes, options))
+
+	// Routes which are silently ignored should be reported
+	routes = []Route{
+		newRoute("a.yaml", "a", nil, "", "Host(`www.example.com`)"),
+		newRoute("a.yaml", "b", map[string]string{
+			"switchboard.borchero.com/ignore": "true",
+		}, "", "Host(`www.example.com`)"),
+	}
+	assert.Equal(t, []string{"a:" + RuleIgnoredRoute}, rulesOf(Lint(routes, options)))
+
+	// Invalid rules and hosts as well as unknown integrations should be reported
+	routes = []Route{newRoute("a.yaml", "a", map[string]string{
+		"kubernetes.io/ingress.class":     "traefik",
+		"switchboard.borchero.com/ignore": "external-dns,certmanager",
+	}, "", "Host(`www.example.com`", "Host(`in_valid.example.com`)")}
+	assert.Equal(t, []string{
+		"a:" + RuleUnknownIntegration, "a:" + RuleInvalidRule, "a:" + RuleInvalidHostname,
+	}, rulesOf(Lint(routes, options)))
+
+	// Conflicts between routes should be reported for all routes
+	routes = []Route{
+		newRoute("a.yaml", "a", annotations, "www-tls", "Host(`www.example.com`)"),
+		newRoute("b.yaml", "b", annotations, "www-tls", "Host(`www.example.com`)"),
+	}
+	findings := Lint(routes, options)
+	assert.Equal(t, []string{
+		"a:" + RuleHostConflict, "b:" + RuleHostConflict,
+		"a:" + RuleTLSSecretCollision, "b:" + RuleTLSSecretCollision,
+	}, rulesOf(findings))
+	assert.Equal(t, "b.yaml", findings[1].File)
+	assert.Equal(t, `Host "www.example.com" is also used by default/a`, findings[1].Message)
+	assert.True(t, HasErrors(findings))
+
+	// Shared TLS secrets are fine if cert-manager does not issue certificates into them...
+	ignoring := map[string]string{
+		"kubernetes.io/ingress.class":     "traefik",
+		"switchboard.borchero.com/ignore": "cert-manager",
+	}
+	routes = []Route{
+		newRoute("a.yaml", "a", ignoring, "wildcard-tls", "Host(`a.example.com`)"),
+		newRoute("b.yaml", "b", ignoring, "wildcard-tls", "Host(`b.example.com`)"),
+	}
+	assert.Empty(t, Lint(routes, options))
+
+	// ...which is also the case if the cert-manager integration is disabled
+	routes = []Route{
+		newRoute("a.yaml", "a", annotations, "wildcard-tls", "Host(`a.example.com`)"),
+		newRoute("b.yaml", "b", annotations, "wildcard-tls", "Host(`b.example.com`)"),
+	}
+	assert.Empty(t, Lint(routes, Options{Selector: options.Selector}))
+}
+
+//-------------------------------------------------------------------------------------------------
+// UTILS
+//-------------------------------------------------------------------------------------------------
+
+func newRoute(
+	file string, name string, annotations map[string]string, secretName string, matches ...string,
+) Route {
+	route := &traefik.IngressRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name, Namespace: "default", Annotations: annotations,
+		},
+	}
+	for _, match := range matches {
+		route.Spec.Routes = append(route.Spec.Routes, traefik.Route{Kind: "Rule", Match: match})
+	}
+	if secretName != "" {
+		route.Spec.TLS = &traefik.TLS{SecretName: secretName}
+	}
+	return Route{File: file, Route: route}
+}
+
+func rulesOf(findings []Finding) []string {
+	result := make([]string, 0, len(findings))
+	for _, f := range findings {
+		result = append(result, f.Name+":"+f.Rule)
+	}
+	return result
+}
@@ -0,0 +1,161 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+const (
+	// FormatText reports findings as human-readable lines.
+	FormatText = "text"
+	// FormatJSON reports findings as JSON array.
+	FormatJSON = "json"
+	// FormatSARIF reports findings as SARIF log, e.g. for code scanning tools.
+	FormatSARIF = "sarif"
+
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	toolName     = "switchboard"
+	toolURI      = "https://github.com/borchero/switchboard"
+)
+
+var ruleDescriptions = map[string]string{
+	RuleInvalidRule:        "The match rule of an ingress route cannot be parsed.",
+	RuleInvalidHostname:    "A host of an ingress route is not a valid DNS name.",
+	RuleHostConflict:       "A host is used by multiple ingress routes.",
+	RuleIgnoredRoute:       "An ingress route is not processed as it does not match the selector.",
+	RuleUnknownIntegration: "The ignore annotation references an integration that is not enabled.",
+	RuleTLSSecretCollision: "A TLS secret is used by multiple ingress routes.",
+}
+
+// Write reports the given findings in the given format.
+func Write(w io.Writer, format string, findings []Finding) error {
+	switch format {
+	case "", FormatText:
+		return writeText(w, findings)
+	case FormatJSON:
+		return writeJSON(w, findings)
+	case FormatSARIF:
+		return writeSARIF(w, findings)
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+//-------------------------------------------------------------------------------------------------
+// FORMATS
+//-------------------------------------------------------------------------------------------------
+
+func writeText(w io.Writer, findings []Finding) error {
+	for _, f := range findings {
+		if _, err := fmt.Fprintf(w, "%s: %s %s/%s: %s [%s]\n",
+			f.File, f.Severity, f.Namespace, f.Name, f.Message, f.Rule,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, findings []Finding) error {
+	if findings == nil {
+		findings = []Finding{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(findings)
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+func writeSARIF(w io.Writer, findings []Finding) error {
+	rules := make([]sarifRule, 0, len(ruleDescriptions))
+	for _, id := range sortedKeys(ruleDescriptions) {
+		rules = append(rules, sarifRule{
+			ID: id, ShortDescription: sarifMessage{Text: ruleDescriptions[id]},
+		})
+	}
+	results := make([]sarifResult, 0, len(findings))
+	for _, f := range findings {
+		results = append(results, sarifResult{
+			RuleID:  f.Rule,
+			Level:   string(f.Severity),
+			Message: sarifMessage{Text: f.Message},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: f.File},
+				},
+				LogicalLocations: []sarifLogicalLocation{{
+					FullyQualifiedName: f.Namespace + "/" + f.Name,
+					Kind:               "resource",
+				}},
+			}},
+		})
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name: toolName, InformationURI: toolURI, Rules: rules,
+			}},
+			Results: results,
+		}},
+	})
+}
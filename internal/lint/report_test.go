@@ -0,0 +1,56 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	findings := []Finding{{
+		Rule:      RuleInvalidRule,
+		Severity:  SeverityError,
+		File:      "routes/a.yaml",
+		Namespace: "default",
+		Name:      "a",
+		Message:   "Rule cannot be parsed",
+	}}
+
+	// Text
+	var buffer bytes.Buffer
+	require.Nil(t, Write(&buffer, FormatText, findings))
+	assert.Equal(t,
+		"routes/a.yaml: error default/a: Rule cannot be parsed [invalid-rule]\n", buffer.String(),
+	)
+
+	// JSON
+	buffer.Reset()
+	require.Nil(t, Write(&buffer, FormatJSON, nil))
+	assert.JSONEq(t, "[]", buffer.String())
+
+	buffer.Reset()
+	require.Nil(t, Write(&buffer, FormatJSON, findings))
+	var decoded []Finding
+	require.Nil(t, json.Unmarshal(buffer.Bytes(), &decoded))
+	assert.Equal(t, findings, decoded)
+
+	// SARIF
+	buffer.Reset()
+	require.Nil(t, Write(&buffer, FormatSARIF, findings))
+	var log sarifLog
+	require.Nil(t, json.Unmarshal(buffer.Bytes(), &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+	assert.Len(t, log.Runs[0].Tool.Driver.Rules, len(ruleDescriptions))
+	require.Len(t, log.Runs[0].Results, 1)
+	result := log.Runs[0].Results[0]
+	assert.Equal(t, "invalid-rule", result.RuleID)
+	assert.Equal(t, "error", result.Level)
+	assert.Equal(t, "routes/a.yaml", result.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+
+	// Unknown formats
+	require.NotNil(t, Write(&buffer, "xml", findings))
+}
@@ -0,0 +1,155 @@
+package switchboard
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+
+	"github.com/borchero/switchboard/internal/k8s"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// IPFamilyPolicy describes which IP families are retained when filtering targets.
+type IPFamilyPolicy string
+
+const (
+	// IPFamilyPolicyDualStack retains both IPv4 and IPv6 addresses.
+	IPFamilyPolicyDualStack IPFamilyPolicy = "DualStack"
+	// IPFamilyPolicyIPv4Only retains only IPv4 addresses.
+	IPFamilyPolicyIPv4Only IPFamilyPolicy = "IPv4Only"
+	// IPFamilyPolicyIPv6Only retains only IPv6 addresses.
+	IPFamilyPolicyIPv6Only IPFamilyPolicy = "IPv6Only"
+	// IPFamilyPolicyPreferIPv4 retains only IPv4 addresses if there are any and falls back to
+	// IPv6 addresses otherwise.
+	IPFamilyPolicyPreferIPv4 IPFamilyPolicy = "PreferIPv4"
+)
+
+// TargetFilter restricts the set of targets by IP family and by CIDR ranges. Hostname targets are
+// never filtered as their IP family cannot be determined.
+type TargetFilter struct {
+	policy IPFamilyPolicy
+	allow  []netip.Prefix
+	deny   []netip.Prefix
+}
+
+// NewTargetFilter creates a new filter from the given IP family policy and CIDR lists. An empty
+// policy defaults to dual-stack. If the allow list is non-empty, IP addresses must be contained in
+// at least one of its ranges. IP addresses contained in any range of the deny list are always
+// removed.
+func NewTargetFilter(policy string, allowCIDRs, denyCIDRs []string) (TargetFilter, error) {
+	filter := TargetFilter{policy: IPFamilyPolicy(policy)}
+	switch filter.policy {
+	case "":
+		filter.policy = IPFamilyPolicyDualStack
+	case IPFamilyPolicyDualStack, IPFamilyPolicyIPv4Only, IPFamilyPolicyIPv6Only,
+		IPFamilyPolicyPreferIPv4:
+	default:
+		return TargetFilter{}, fmt.Errorf("unknown IP family policy %q", policy)
+	}
+
+	var err error
+	if filter.allow, err = parsePrefixes(allowCIDRs); err != nil {
+		return TargetFilter{}, fmt.Errorf("invalid allowed CIDR: %w", err)
+	}
+	if filter.deny, err = parsePrefixes(denyCIDRs); err != nil {
+		return TargetFilter{}, fmt.Errorf("invalid denied CIDR: %w", err)
+	}
+	return filter, nil
+}
+
+// Apply returns the subset of the provided targets that passes the filter. Hostnames are returned
+// first, followed by IPv4 and IPv6 addresses in their original order.
+func (f TargetFilter) Apply(targets []string) []string {
+	hostnames := make([]string, 0)
+	ipv4 := make([]string, 0)
+	ipv6 := make([]string, 0)
+	for _, target := range targets {
+		addr, err := netip.ParseAddr(target)
+		if err != nil {
+			hostnames = append(hostnames, target)
+			continue
+		}
+		if !f.admits(addr) {
+			continue
+		}
+		if addr.Unmap().Is4() {
+			ipv4 = append(ipv4, target)
+		} else {
+			ipv6 = append(ipv6, target)
+		}
+	}
+
+	result := hostnames
+	switch f.policy {
+	case IPFamilyPolicyIPv4Only:
+		result = append(result, ipv4...)
+	case IPFamilyPolicyIPv6Only:
+		result = append(result, ipv6...)
+	case IPFamilyPolicyPreferIPv4:
+		if len(ipv4) > 0 {
+			result = append(result, ipv4...)
+		} else {
+			result = append(result, ipv6...)
+		}
+	default:
+		result = append(append(result, ipv4...), ipv6...)
+	}
+	return result
+}
+
+func (f TargetFilter) admits(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range f.deny {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	if len(f.allow) == 0 {
+		return true
+	}
+	for _, prefix := range f.allow {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func parsePrefixes(cidrs []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+//-------------------------------------------------------------------------------------------------
+// FILTERED TARGET
+//-------------------------------------------------------------------------------------------------
+
+type filteredTarget struct {
+	target Target
+	filter TargetFilter
+}
+
+// NewFilteredTarget wraps the provided target such that all of its targets are passed through the
+// given filter.
+func NewFilteredTarget(target Target, filter TargetFilter) Target {
+	return filteredTarget{target, filter}
+}
+
+func (t filteredTarget) Targets(ctx context.Context, client client.Client) ([]string, error) {
+	targets, err := t.target.Targets(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	return t.filter.Apply(targets), nil
+}
+
+func (t filteredTarget) Watches() []k8s.Watch {
+	return t.target.Watches()
+}
@@ -0,0 +1,91 @@
+package switchboard
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTargetFilter(t *testing.T) {
+	filter, err := NewTargetFilter("", nil, nil)
+	require.Nil(t, err)
+	assert.Equal(t, IPFamilyPolicyDualStack, filter.policy)
+
+	_, err = NewTargetFilter("IPv5Only", nil, nil)
+	assert.NotNil(t, err)
+
+	_, err = NewTargetFilter("IPv4Only", []string{"10.0.0.0"}, nil)
+	assert.NotNil(t, err)
+
+	_, err = NewTargetFilter("IPv4Only", nil, []string{"invalid"})
+	assert.NotNil(t, err)
+}
+
+func TestTargetFilterPolicy(t *testing.T) {
+	targets := []string{"10.0.0.5", "2001:db8::1", "192.168.0.1", "2001:db8::2"}
+
+	filter, err := NewTargetFilter("DualStack", nil, nil)
+	require.Nil(t, err)
+	assert.ElementsMatch(t, targets, filter.Apply(targets))
+
+	filter, err = NewTargetFilter("IPv4Only", nil, nil)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"10.0.0.5", "192.168.0.1"}, filter.Apply(targets))
+
+	filter, err = NewTargetFilter("IPv6Only", nil, nil)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"2001:db8::1", "2001:db8::2"}, filter.Apply(targets))
+
+	filter, err = NewTargetFilter("PreferIPv4", nil, nil)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"10.0.0.5", "192.168.0.1"}, filter.Apply(targets))
+	assert.Equal(t, []string{"2001:db8::1"}, filter.Apply([]string{"2001:db8::1"}))
+
+	// Hostnames are never filtered
+	filter, err = NewTargetFilter("IPv6Only", nil, nil)
+	require.Nil(t, err)
+	assert.Equal(
+		t,
+		[]string{"example.lb.identifier.amazonaws.com"},
+		filter.Apply([]string{"example.lb.identifier.amazonaws.com"}),
+	)
+}
+
+func TestTargetFilterCIDRs(t *testing.T) {
+	targets := []string{"10.0.0.5", "10.1.0.5", "2001:db8::1", "fd00::1"}
+
+	filter, err := NewTargetFilter("", []string{"10.0.0.0/8", "2001:db8::/32"}, nil)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"10.0.0.5", "10.1.0.5", "2001:db8::1"}, filter.Apply(targets))
+
+	filter, err = NewTargetFilter("", nil, []string{"10.1.0.0/16", "fd00::/8"})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"10.0.0.5", "2001:db8::1"}, filter.Apply(targets))
+
+	// Deny takes precedence over allow
+	filter, err = NewTargetFilter("", []string{"10.0.0.0/8"}, []string{"10.1.0.0/16"})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"10.0.0.5"}, filter.Apply(targets))
+
+	// Policy is applied after CIDR filtering
+	filter, err = NewTargetFilter("PreferIPv4", nil, []string{"10.0.0.0/8"})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"2001:db8::1", "fd00::1"}, filter.Apply(targets))
+}
+
+func TestFilteredTarget(t *testing.T) {
+	ctx := context.Background()
+	filter, err := NewTargetFilter("IPv4Only", nil, nil)
+	require.Nil(t, err)
+
+	target := NewFilteredTarget(NewStaticTarget("127.0.0.1", "2001:db8::1"), filter)
+	ips, err := target.Targets(ctx, nil)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"127.0.0.1"}, ips)
+	assert.Len(t, target.Watches(), 0)
+
+	target = NewFilteredTarget(NewServiceTarget("my-service", "my-namespace"), filter)
+	assert.Len(t, target.Watches(), 1)
+}
@@ -0,0 +1,43 @@
+package switchboard
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+const dnsRecordsAnnotationKey = "switchboard.borchero.com/dns-records"
+
+// DNSRecord describes an additional DNS record that is requested for an ingress.
+type DNSRecord struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Targets []string `json:"targets"`
+	TTL     *int64   `json:"ttl,omitempty"`
+}
+
+// DNSRecordsFromAnnotations parses the additional DNS records from the
+// `switchboard.borchero.com/dns-records` annotation. The annotation is expected to contain a YAML
+// (or JSON) list of records. If the annotation is not set, no records are returned.
+func DNSRecordsFromAnnotations(annotations map[string]string) ([]DNSRecord, error) {
+	annotation, ok := annotations[dnsRecordsAnnotationKey]
+	if !ok {
+		return nil, nil
+	}
+
+	var records []DNSRecord
+	if err := yaml.UnmarshalStrict([]byte(annotation), &records); err != nil {
+		return nil, fmt.Errorf("failed to parse DNS records annotation: %s", err)
+	}
+	for i, record := range records {
+		if record.Name == "" || record.Type == "" || len(record.Targets) == 0 {
+			return nil, fmt.Errorf(
+				"DNS record at index %d must specify a name, a type and at least one target", i,
+			)
+		}
+		records[i].Name = strings.TrimSuffix(strings.ToLower(record.Name), ".")
+		records[i].Type = strings.ToUpper(record.Type)
+	}
+	return records, nil
+}
@@ -0,0 +1,61 @@
+package switchboard
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDNSRecordsFromAnnotations(t *testing.T) {
+	// No annotation
+	records, err := DNSRecordsFromAnnotations(map[string]string{})
+	require.Nil(t, err)
+	assert.Len(t, records, 0)
+
+	// YAML annotation
+	records, err = DNSRecordsFromAnnotations(map[string]string{
+		"switchboard.borchero.com/dns-records": `
+- name: _verification.Example.com.
+  type: txt
+  targets: ["token=abc"]
+- name: example.com
+  type: MX
+  targets: ["10 mail.example.com"]
+  ttl: 3600
+`,
+	})
+	require.Nil(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "_verification.example.com", records[0].Name)
+	assert.Equal(t, "TXT", records[0].Type)
+	assert.Equal(t, []string{"token=abc"}, records[0].Targets)
+	assert.Nil(t, records[0].TTL)
+	assert.Equal(t, "MX", records[1].Type)
+	assert.Equal(t, int64(3600), *records[1].TTL)
+
+	// JSON annotation
+	records, err = DNSRecordsFromAnnotations(map[string]string{
+		"switchboard.borchero.com/dns-records": `[{"name": "example.com", "type": "TXT", ` +
+			`"targets": ["v=spf1 -all"]}]`,
+	})
+	require.Nil(t, err)
+	assert.Len(t, records, 1)
+
+	// Invalid annotations
+	_, err = DNSRecordsFromAnnotations(map[string]string{
+		"switchboard.borchero.com/dns-records": `name: example.com`,
+	})
+	assert.NotNil(t, err)
+
+	_, err = DNSRecordsFromAnnotations(map[string]string{
+		"switchboard.borchero.com/dns-records": `[{"name": "example.com", "type": "TXT"}]`,
+	})
+	assert.NotNil(t, err)
+
+	_, err = DNSRecordsFromAnnotations(map[string]string{
+		"switchboard.borchero.com/dns-records": `[{"name": "example.com", "kind": "TXT", ` +
+			`"targets": ["v=spf1 -all"]}]`,
+	})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,26 @@
+package switchboard
+
+import (
+	"slices"
+	"strings"
+)
+
+const reflectTLSAnnotationKey = "switchboard.borchero.com/reflect-tls-to"
+
+// ReflectionNamespacesFromAnnotations returns the namespaces that the TLS secret of an ingress
+// should be copied to as set via the comma-separated `switchboard.borchero.com/reflect-tls-to`
+// annotation. If the annotation is not set, no namespaces are returned.
+func ReflectionNamespacesFromAnnotations(annotations map[string]string) []string {
+	annotation, ok := annotations[reflectTLSAnnotationKey]
+	if !ok {
+		return nil
+	}
+	namespaces := make([]string, 0)
+	for namespace := range strings.SplitSeq(annotation, ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	slices.Sort(namespaces)
+	return slices.Compact(namespaces)
+}
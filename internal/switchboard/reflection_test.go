@@ -0,0 +1,17 @@
+package switchboard
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReflectionNamespacesFromAnnotations(t *testing.T) {
+	namespaces := ReflectionNamespacesFromAnnotations(map[string]string{})
+	assert.Len(t, namespaces, 0)
+
+	namespaces = ReflectionNamespacesFromAnnotations(map[string]string{
+		"switchboard.borchero.com/reflect-tls-to": "tenant-b, tenant-a,,tenant-b",
+	})
+	assert.Equal(t, []string{"tenant-a", "tenant-b"}, namespaces)
+}
@@ -57,3 +57,19 @@ func (Selector) MatchesIntegration(annotations map[string]string, integration st
 	}
 	return true
 }
+
+// IgnoredIntegrations returns the names of the integrations listed in the ignore annotation. If
+// all integrations are ignored, `nil` is returned.
+func (Selector) IgnoredIntegrations(annotations map[string]string) []string {
+	ignore, ok := annotations[ignoreAnnotationKey]
+	if !ok || ignore == "true" || ignore == "all" {
+		return nil
+	}
+	var result []string
+	for _, ignored := range strings.Split(ignore, ",") {
+		if name := strings.TrimSpace(ignored); name != "" {
+			result = append(result, name)
+		}
+	}
+	return result
+}
@@ -104,3 +104,14 @@ func TestMatchesIntegration(t *testing.T) {
 		"switchboard.borchero.com/ignore": "external-dns, cert-manager",
 	}, "unknown"))
 }
+
+func TestIgnoredIntegrations(t *testing.T) {
+	selector := NewSelector(nil)
+	assert.Nil(t, selector.IgnoredIntegrations(map[string]string{}))
+	assert.Nil(t, selector.IgnoredIntegrations(map[string]string{
+		"switchboard.borchero.com/ignore": "all",
+	}))
+	assert.Equal(t, []string{"external-dns", "cert-manager"}, selector.IgnoredIntegrations(
+		map[string]string{"switchboard.borchero.com/ignore": "external-dns, cert-manager,"},
+	))
+}
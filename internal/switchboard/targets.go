@@ -3,11 +3,18 @@ package switchboard
 import (
 	"context"
 	"fmt"
+	"net/netip"
+	"slices"
 	"strings"
 
+	"github.com/borchero/switchboard/internal/k8s"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
 
 const HostnameKey = "external-dns.alpha.kubernetes.io/hostname"
@@ -17,9 +24,9 @@ type Target interface {
 	// Targets returns the IPv4/IPv6 addresses or hostnames that should be used as targets or an
 	// error if the addresses/hostnames cannot be retrieved.
 	Targets(ctx context.Context, client client.Client) ([]string, error)
-	// NamespacedName returns the namespaced name of the dynamic target service or none if the IP
-	// is not retrieved dynamically.
-	NamespacedName() *types.NamespacedName
+	// Watches returns the Kubernetes objects whose changes may alter the targets. If the targets
+	// are not retrieved dynamically, no watches are returned.
+	Watches() []k8s.Watch
 }
 
 //-------------------------------------------------------------------------------------------------
@@ -48,30 +55,92 @@ func extractHostnamesFromAnnotations(input map[string]string, key string) []stri
 // SERVICE TARGET
 //-------------------------------------------------------------------------------------------------
 
+// ServiceSelector selects Kubernetes services in a namespace. If a name is set, it selects the
+// service with this name. Otherwise, it selects all services matching the label selector.
+type ServiceSelector struct {
+	Name      string
+	Namespace string
+	Labels    labels.Selector
+}
+
+func (s ServiceSelector) matches(obj client.Object) bool {
+	if obj.GetNamespace() != s.Namespace {
+		return false
+	}
+	if s.Name != "" {
+		return obj.GetName() == s.Name
+	}
+	return s.Labels.Matches(labels.Set(obj.GetLabels()))
+}
+
 type serviceTarget struct {
-	name types.NamespacedName
+	selectors []ServiceSelector
+	clusterIP bool
 }
 
 // NewServiceTarget creates a new target which dynamically sources the IP from the provided
 // Kubernetes service.
 func NewServiceTarget(name, namespace string) Target {
-	return serviceTarget{
-		name: types.NamespacedName{Name: name, Namespace: namespace},
-	}
+	return NewServicesTarget(ServiceSelector{Name: name, Namespace: namespace})
 }
 
-func (t serviceTarget) Targets(ctx context.Context, client client.Client) ([]string, error) {
-	// Get service
-	var service v1.Service
-	if err := client.Get(ctx, t.name, &service); err != nil {
-		return nil, fmt.Errorf("failed to query service: %w", err)
+// NewServicesTarget creates a new target which dynamically sources the IPs from all services
+// matched by any of the provided selectors. The targets of all services are merged.
+func NewServicesTarget(selectors ...ServiceSelector) Target {
+	return serviceTarget{selectors, false}
+}
+
+// NewClusterIPServicesTarget creates a new target which dynamically sources the cluster IPs from
+// all services matched by any of the provided selectors. In contrast to `NewServicesTarget`, load
+// balancer addresses and hostname annotations are ignored such that the targets are only
+// reachable from within the cluster. Headless services do not provide any targets.
+func NewClusterIPServicesTarget(selectors ...ServiceSelector) Target {
+	return serviceTarget{selectors, true}
+}
+
+func (t serviceTarget) Targets(ctx context.Context, ctrlClient client.Client) ([]string, error) {
+	targets := make([][]string, 0)
+	for _, selector := range t.selectors {
+		// Get service by name...
+		if selector.Name != "" {
+			var service v1.Service
+			name := types.NamespacedName{Name: selector.Name, Namespace: selector.Namespace}
+			if err := ctrlClient.Get(ctx, name, &service); err != nil {
+				return nil, fmt.Errorf("failed to query service: %w", err)
+			}
+			targets = append(targets, t.targetsFromService(service))
+			continue
+		}
+
+		// ...or list services by labels
+		var services v1.ServiceList
+		if err := ctrlClient.List(
+			ctx, &services,
+			client.InNamespace(selector.Namespace),
+			client.MatchingLabelsSelector{Selector: selector.Labels},
+		); err != nil {
+			return nil, fmt.Errorf("failed to list services: %w", err)
+		}
+		for _, service := range services.Items {
+			targets = append(targets, t.targetsFromService(service))
+		}
 	}
-	return t.targetsFromService(service), nil
+	return mergeTargets(targets...), nil
 }
 
-func (serviceTarget) targetsFromService(service v1.Service) []string {
+func (t serviceTarget) targetsFromService(service v1.Service) []string {
 	targets := make([]string, 0)
 
+	// Cluster IP targets only consider the cluster IPs
+	if t.clusterIP {
+		for _, ip := range service.Spec.ClusterIPs {
+			if ip != "" && ip != v1.ClusterIPNone {
+				targets = append(targets, ip)
+			}
+		}
+		return targets
+	}
+
 	// Prioritize annotation hostnames.
 	hostnameList := HostnamesFromAnnotations(service.Annotations)
 	for _, hostname := range hostnameList {
@@ -101,8 +170,151 @@ func (serviceTarget) targetsFromService(service v1.Service) []string {
 	return targets
 }
 
-func (t serviceTarget) NamespacedName() *types.NamespacedName {
-	return &t.name
+func (t serviceTarget) Watches() []k8s.Watch {
+	watches := make([]k8s.Watch, 0, len(t.selectors))
+	for _, selector := range t.selectors {
+		if selector.Name != "" {
+			watches = append(watches, k8s.WatchObject(&v1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: selector.Name, Namespace: selector.Namespace},
+			}))
+			continue
+		}
+		// For label selectors, services entering or leaving the selection must be considered
+		watches = append(watches, k8s.Watch{
+			Object: &v1.Service{},
+			Predicates: []predicate.Predicate{predicate.Funcs{
+				CreateFunc: func(e event.CreateEvent) bool {
+					return selector.matches(e.Object)
+				},
+				UpdateFunc: func(e event.UpdateEvent) bool {
+					return selector.matches(e.ObjectOld) || selector.matches(e.ObjectNew)
+				},
+				DeleteFunc: func(e event.DeleteEvent) bool {
+					return selector.matches(e.Object)
+				},
+				GenericFunc: func(e event.GenericEvent) bool {
+					return selector.matches(e.Object)
+				},
+			}},
+		})
+	}
+	return watches
+}
+
+// mergeTargets merges the targets obtained from multiple sources and removes duplicates. As there
+// cannot be more than one CNAME record, the first hostname overwrites everything if any source
+// provides a hostname.
+func mergeTargets(targets ...[]string) []string {
+	result := make([]string, 0)
+	seen := make(map[string]struct{})
+	for _, values := range targets {
+		for _, target := range values {
+			if _, err := netip.ParseAddr(target); err != nil {
+				return []string{target}
+			}
+			if _, ok := seen[target]; !ok {
+				seen[target] = struct{}{}
+				result = append(result, target)
+			}
+		}
+	}
+	return result
+}
+
+//-------------------------------------------------------------------------------------------------
+// NODE TARGET
+//-------------------------------------------------------------------------------------------------
+
+type nodeTarget struct {
+	selector     labels.Selector
+	addressTypes []v1.NodeAddressType
+}
+
+// NewNodeTarget creates a new target which dynamically sources the IPs from all ready and
+// schedulable nodes matching the provided selector. For each node, the addresses of the first
+// address type that the node provides are used. If no address types are given, external IPs are
+// preferred over internal IPs.
+func NewNodeTarget(selector labels.Selector, addressTypes ...v1.NodeAddressType) Target {
+	if len(addressTypes) == 0 {
+		addressTypes = []v1.NodeAddressType{v1.NodeExternalIP, v1.NodeInternalIP}
+	}
+	return nodeTarget{selector, addressTypes}
+}
+
+func (t nodeTarget) Targets(ctx context.Context, ctrlClient client.Client) ([]string, error) {
+	// List nodes
+	var nodes v1.NodeList
+	if err := ctrlClient.List(
+		ctx, &nodes, client.MatchingLabelsSelector{Selector: t.selector},
+	); err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	// Aggregate the addresses of all nodes, ensuring a stable order
+	targets := make([]string, 0)
+	for _, node := range nodes.Items {
+		targets = append(targets, t.targetsFromNode(&node)...)
+	}
+	slices.Sort(targets)
+	return slices.Compact(targets), nil
+}
+
+func (t nodeTarget) targetsFromNode(node *v1.Node) []string {
+	// Cordoned and unready nodes do not provide any targets
+	if node.Spec.Unschedulable || !isNodeReady(node) {
+		return nil
+	}
+
+	// Otherwise, use the addresses of the first address type available
+	for _, addressType := range t.addressTypes {
+		targets := make([]string, 0)
+		for _, address := range node.Status.Addresses {
+			if address.Type == addressType && address.Address != "" {
+				targets = append(targets, address.Address)
+			}
+		}
+		if len(targets) > 0 {
+			return targets
+		}
+	}
+	return nil
+}
+
+func (t nodeTarget) Watches() []k8s.Watch {
+	// Membership changes whenever the targets derived from a (matching) node change
+	targetsOf := func(obj client.Object) []string {
+		node, ok := obj.(*v1.Node)
+		if !ok || !t.selector.Matches(labels.Set(node.Labels)) {
+			return nil
+		}
+		return t.targetsFromNode(node)
+	}
+	return []k8s.Watch{{
+		Object: &v1.Node{},
+		Predicates: []predicate.Predicate{predicate.Funcs{
+			CreateFunc: func(e event.CreateEvent) bool {
+				return len(targetsOf(e.Object)) > 0
+			},
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				return !slices.Equal(targetsOf(e.ObjectOld), targetsOf(e.ObjectNew))
+			},
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return len(targetsOf(e.Object)) > 0
+			},
+			GenericFunc: func(event.GenericEvent) bool {
+				return false
+			},
+		}},
+	}}
+}
+
+func isNodeReady(node *v1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
 }
 
 //-------------------------------------------------------------------------------------------------
@@ -123,6 +335,6 @@ func (t staticTarget) Targets(ctx context.Context, client client.Client) ([]stri
 	return t.ips, nil
 }
 
-func (staticTarget) NamespacedName() *types.NamespacedName {
+func (staticTarget) Watches() []k8s.Watch {
 	return nil
 }
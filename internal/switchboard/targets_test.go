@@ -9,7 +9,10 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 )
 
 func TestServiceTargetTargets(t *testing.T) {
@@ -46,6 +49,51 @@ func TestServiceTargetTargets(t *testing.T) {
 	assert.ElementsMatch(t, []string{service.Status.LoadBalancer.Ingress[0].IP}, targets)
 }
 
+func TestServicesTargetTargets(t *testing.T) {
+	// Setup
+	ctx := context.Background()
+	scheme := k8tests.NewScheme()
+	ctrlClient := k8tests.NewClient(t, scheme)
+	namespace, shutdown := k8tests.NewNamespace(ctx, t, ctrlClient)
+	defer shutdown()
+
+	// Create a couple of services
+	service1 := k8tests.DummyService("my-service-1", namespace, 80)
+	err := ctrlClient.Create(ctx, &service1)
+	require.Nil(t, err)
+	service2 := k8tests.DummyService("my-service-2", namespace, 80)
+	service2.Labels = map[string]string{"app.kubernetes.io/name": "traefik"}
+	err = ctrlClient.Create(ctx, &service2)
+	require.Nil(t, err)
+	service3 := k8tests.DummyService("my-service-3", namespace, 80)
+	service3.Labels = map[string]string{"app.kubernetes.io/name": "traefik"}
+	err = ctrlClient.Create(ctx, &service3)
+	require.Nil(t, err)
+
+	// Check whether we find the cluster IPs of all services
+	target := NewServicesTarget(ServiceSelector{
+		Name: service1.Name, Namespace: namespace,
+	}, ServiceSelector{
+		Namespace: namespace,
+		Labels:    labels.SelectorFromSet(labels.Set{"app.kubernetes.io/name": "traefik"}),
+	})
+	targets, err := target.Targets(ctx, ctrlClient)
+	require.Nil(t, err)
+	expected := append(append(service1.Spec.ClusterIPs, service2.Spec.ClusterIPs...),
+		service3.Spec.ClusterIPs...)
+	assert.ElementsMatch(t, expected, targets)
+
+	// Duplicate selections should not result in duplicate targets
+	target = NewServicesTarget(ServiceSelector{
+		Name: service1.Name, Namespace: namespace,
+	}, ServiceSelector{
+		Name: service1.Name, Namespace: namespace,
+	})
+	targets, err = target.Targets(ctx, ctrlClient)
+	require.Nil(t, err)
+	assert.ElementsMatch(t, service1.Spec.ClusterIPs, targets)
+}
+
 func TestServiceTargetTargetsFromService(t *testing.T) {
 	var target serviceTarget
 
@@ -119,11 +167,151 @@ func TestServiceTargetTargetsFromService(t *testing.T) {
 	assert.ElementsMatch(t, []string{"example.identifier.amazonaws.com"}, targets)
 }
 
-func TestServiceTargetNamespacedName(t *testing.T) {
+func TestClusterIPServiceTargetTargetsFromService(t *testing.T) {
+	target := serviceTarget{clusterIP: true}
+
+	// Load balancer addresses and annotations are ignored
+	service := v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{HostnameKey: "example.identifier.amazonaws.com"},
+		},
+		Spec: v1.ServiceSpec{ClusterIPs: []string{"10.0.0.5", "2001:db8::1"}},
+	}
+	service.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{IP: "192.168.5.5"}}
+	targets := target.targetsFromService(service)
+	assert.ElementsMatch(t, []string{"10.0.0.5", "2001:db8::1"}, targets)
+
+	// Headless services do not provide targets
+	service.Spec.ClusterIPs = []string{v1.ClusterIPNone}
+	targets = target.targetsFromService(service)
+	assert.Len(t, targets, 0)
+}
+
+func TestServiceTargetWatches(t *testing.T) {
 	target := NewServiceTarget("my-service", "my-namespace")
-	name := target.NamespacedName()
-	assert.Equal(t, "my-service", name.Name)
-	assert.Equal(t, "my-namespace", name.Namespace)
+	watches := target.Watches()
+	require.Len(t, watches, 1)
+	assert.IsType(t, &v1.Service{}, watches[0].Object)
+
+	service := k8tests.DummyService("my-service", "my-namespace", 80)
+	other := k8tests.DummyService("my-service", "your-namespace", 80)
+	require.Len(t, watches[0].Predicates, 1)
+	assert.True(t, watches[0].Predicates[0].Generic(event.GenericEvent{Object: &service}))
+	assert.False(t, watches[0].Predicates[0].Generic(event.GenericEvent{Object: &other}))
+}
+
+func TestServicesTargetWatches(t *testing.T) {
+	target := NewServicesTarget(ServiceSelector{
+		Name: "my-service", Namespace: "my-namespace",
+	}, ServiceSelector{
+		Namespace: "my-namespace",
+		Labels:    labels.SelectorFromSet(labels.Set{"app.kubernetes.io/name": "traefik"}),
+	})
+	watches := target.Watches()
+	require.Len(t, watches, 2)
+	require.Len(t, watches[1].Predicates, 1)
+	predicate := watches[1].Predicates[0]
+
+	matching := k8tests.DummyService("your-service", "my-namespace", 80)
+	matching.Labels = map[string]string{"app.kubernetes.io/name": "traefik"}
+	other := k8tests.DummyService("your-service", "my-namespace", 80)
+	otherNamespace := k8tests.DummyService("your-service", "your-namespace", 80)
+	otherNamespace.Labels = matching.Labels
+
+	assert.True(t, predicate.Create(event.CreateEvent{Object: &matching}))
+	assert.False(t, predicate.Create(event.CreateEvent{Object: &other}))
+	assert.False(t, predicate.Create(event.CreateEvent{Object: &otherNamespace}))
+	assert.True(t, predicate.Delete(event.DeleteEvent{Object: &matching}))
+
+	// Services leaving the selection must be considered
+	assert.True(t, predicate.Update(event.UpdateEvent{ObjectOld: &matching, ObjectNew: &other}))
+	assert.False(t, predicate.Update(event.UpdateEvent{ObjectOld: &other, ObjectNew: &other}))
+}
+
+func TestMergeTargets(t *testing.T) {
+	// IPs are merged and deduplicated
+	assert.Equal(t, []string{"10.0.0.5", "2001:db8::1", "10.0.0.6"}, mergeTargets(
+		[]string{"10.0.0.5", "2001:db8::1"}, []string{"10.0.0.6", "10.0.0.5"},
+	))
+
+	// Hostnames overwrite everything
+	assert.Equal(t, []string{"example.lb.identifier.amazonaws.com"}, mergeTargets(
+		[]string{"10.0.0.5"},
+		[]string{"example.lb.identifier.amazonaws.com"},
+		[]string{"example2.lb.identifier.amazonaws.com"},
+	))
+
+	assert.Len(t, mergeTargets(), 0)
+}
+
+func TestNodeTargetTargetsFromNode(t *testing.T) {
+	target := NewNodeTarget(labels.Everything()).(nodeTarget)
+
+	// Source external IPs
+	node := dummyNode("my-node", nil, v1.ConditionTrue,
+		v1.NodeAddress{Type: v1.NodeInternalIP, Address: "10.0.0.5"},
+		v1.NodeAddress{Type: v1.NodeExternalIP, Address: "192.168.5.5"},
+		v1.NodeAddress{Type: v1.NodeExternalIP, Address: "2001:db8::1"},
+		v1.NodeAddress{Type: v1.NodeHostName, Address: "my-node"},
+	)
+	assert.ElementsMatch(t, []string{"192.168.5.5", "2001:db8::1"}, target.targetsFromNode(&node))
+
+	// Fall back to internal IPs
+	node.Status.Addresses = node.Status.Addresses[:1]
+	assert.ElementsMatch(t, []string{"10.0.0.5"}, target.targetsFromNode(&node))
+
+	// Respect custom address types
+	target = NewNodeTarget(labels.Everything(), v1.NodeExternalIP).(nodeTarget)
+	assert.Len(t, target.targetsFromNode(&node), 0)
+
+	// Ignore unready and cordoned nodes
+	target = NewNodeTarget(labels.Everything()).(nodeTarget)
+	node = dummyNode("my-node", nil, v1.ConditionFalse,
+		v1.NodeAddress{Type: v1.NodeExternalIP, Address: "192.168.5.5"},
+	)
+	assert.Len(t, target.targetsFromNode(&node), 0)
+	node.Status.Conditions = nil
+	assert.Len(t, target.targetsFromNode(&node), 0)
+
+	node = dummyNode("my-node", nil, v1.ConditionTrue,
+		v1.NodeAddress{Type: v1.NodeExternalIP, Address: "192.168.5.5"},
+	)
+	node.Spec.Unschedulable = true
+	assert.Len(t, target.targetsFromNode(&node), 0)
+}
+
+func TestNodeTargetWatches(t *testing.T) {
+	selector := labels.SelectorFromSet(labels.Set{"ingress": "true"})
+	watches := NewNodeTarget(selector).Watches()
+	require.Len(t, watches, 1)
+	assert.IsType(t, &v1.Node{}, watches[0].Object)
+	require.Len(t, watches[0].Predicates, 1)
+	predicate := watches[0].Predicates[0]
+
+	matching := dummyNode("my-node", map[string]string{"ingress": "true"}, v1.ConditionTrue,
+		v1.NodeAddress{Type: v1.NodeExternalIP, Address: "192.168.5.5"},
+	)
+	other := dummyNode("my-node", nil, v1.ConditionTrue,
+		v1.NodeAddress{Type: v1.NodeExternalIP, Address: "192.168.5.5"},
+	)
+
+	// Only matching nodes trigger on creation and deletion
+	assert.True(t, predicate.Create(event.CreateEvent{Object: &matching}))
+	assert.False(t, predicate.Create(event.CreateEvent{Object: &other}))
+	assert.True(t, predicate.Delete(event.DeleteEvent{Object: &matching}))
+	assert.False(t, predicate.Delete(event.DeleteEvent{Object: &other}))
+
+	// Updates only trigger if membership changes
+	assert.False(t, predicate.Update(event.UpdateEvent{
+		ObjectOld: &matching, ObjectNew: matching.DeepCopy(),
+	}))
+	assert.True(t, predicate.Update(event.UpdateEvent{ObjectOld: &other, ObjectNew: &matching}))
+	cordoned := matching.DeepCopy()
+	cordoned.Spec.Unschedulable = true
+	assert.True(t, predicate.Update(event.UpdateEvent{ObjectOld: &matching, ObjectNew: cordoned}))
+	unready := matching.DeepCopy()
+	unready.Status.Conditions[0].Status = v1.ConditionUnknown
+	assert.True(t, predicate.Update(event.UpdateEvent{ObjectOld: &matching, ObjectNew: unready}))
 }
 
 func TestStaticTargetIPs(t *testing.T) {
@@ -134,3 +322,20 @@ func TestStaticTargetIPs(t *testing.T) {
 	require.Nil(t, err)
 	assert.ElementsMatch(t, expectedIPs, ips)
 }
+
+//-------------------------------------------------------------------------------------------------
+// UTILS
+//-------------------------------------------------------------------------------------------------
+
+func dummyNode(
+	name string, nodeLabels map[string]string, ready v1.ConditionStatus,
+	addresses ...v1.NodeAddress,
+) v1.Node {
+	return v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: nodeLabels},
+		Status: v1.NodeStatus{
+			Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: ready}},
+			Addresses:  addresses,
+		},
+	}
+}
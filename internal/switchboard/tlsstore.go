@@ -0,0 +1,40 @@
+package switchboard
+
+import "fmt"
+
+const tlsStoreAnnotationKey = "switchboard.borchero.com/tls-store"
+
+// TLSStoreMode describes how the TLS secret of an ingress is registered in a Traefik TLS store.
+type TLSStoreMode string
+
+const (
+	// TLSStoreModeNone does not register the TLS secret.
+	TLSStoreModeNone TLSStoreMode = "none"
+	// TLSStoreModeCertificate adds the TLS secret to the certificates of the TLS store.
+	TLSStoreModeCertificate TLSStoreMode = "certificate"
+	// TLSStoreModeDefault sets the TLS secret as the default certificate of the TLS store.
+	TLSStoreModeDefault TLSStoreMode = "default"
+)
+
+// ParseTLSStoreMode parses the provided TLS store mode and returns an error if it is unknown.
+func ParseTLSStoreMode(mode string) (TLSStoreMode, error) {
+	switch TLSStoreMode(mode) {
+	case TLSStoreModeNone, TLSStoreModeCertificate, TLSStoreModeDefault:
+		return TLSStoreMode(mode), nil
+	default:
+		return "", fmt.Errorf("unknown TLS store mode %q", mode)
+	}
+}
+
+// TLSStoreModeFromAnnotations returns the TLS store mode set via the
+// `switchboard.borchero.com/tls-store` annotation. If the annotation is not set, the provided
+// fallback is returned.
+func TLSStoreModeFromAnnotations(
+	annotations map[string]string, fallback TLSStoreMode,
+) (TLSStoreMode, error) {
+	annotation, ok := annotations[tlsStoreAnnotationKey]
+	if !ok {
+		return fallback, nil
+	}
+	return ParseTLSStoreMode(annotation)
+}
@@ -0,0 +1,36 @@
+package switchboard
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTLSStoreMode(t *testing.T) {
+	for _, mode := range []string{"none", "certificate", "default"} {
+		parsed, err := ParseTLSStoreMode(mode)
+		require.Nil(t, err)
+		assert.Equal(t, TLSStoreMode(mode), parsed)
+	}
+
+	_, err := ParseTLSStoreMode("Default")
+	assert.NotNil(t, err)
+}
+
+func TestTLSStoreModeFromAnnotations(t *testing.T) {
+	mode, err := TLSStoreModeFromAnnotations(map[string]string{}, TLSStoreModeCertificate)
+	require.Nil(t, err)
+	assert.Equal(t, TLSStoreModeCertificate, mode)
+
+	mode, err = TLSStoreModeFromAnnotations(map[string]string{
+		"switchboard.borchero.com/tls-store": "default",
+	}, TLSStoreModeNone)
+	require.Nil(t, err)
+	assert.Equal(t, TLSStoreModeDefault, mode)
+
+	_, err = TLSStoreModeFromAnnotations(map[string]string{
+		"switchboard.borchero.com/tls-store": "invalid",
+	}, TLSStoreModeNone)
+	assert.NotNil(t, err)
+}
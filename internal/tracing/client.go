@@ -0,0 +1,172 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+type tracingClient struct {
+	client.Client
+	tracer trace.Tracer
+}
+
+// NewClient wraps the given client such that a span is created for every API call.
+func NewClient(c client.Client, provider trace.TracerProvider) client.Client {
+	return &tracingClient{Client: c, tracer: Tracer(provider)}
+}
+
+func (c *tracingClient) Get(
+	ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption,
+) error {
+	ctx, span := c.start(ctx, "Get", obj, key.Namespace, key.Name)
+	return end(span, c.Client.Get(ctx, key, obj, opts...))
+}
+
+func (c *tracingClient) List(
+	ctx context.Context, list client.ObjectList, opts ...client.ListOption,
+) error {
+	ctx, span := c.start(ctx, "List", list, "", "")
+	return end(span, c.Client.List(ctx, list, opts...))
+}
+
+func (c *tracingClient) Create(
+	ctx context.Context, obj client.Object, opts ...client.CreateOption,
+) error {
+	ctx, span := c.start(ctx, "Create", obj, obj.GetNamespace(), obj.GetName())
+	return end(span, c.Client.Create(ctx, obj, opts...))
+}
+
+func (c *tracingClient) Update(
+	ctx context.Context, obj client.Object, opts ...client.UpdateOption,
+) error {
+	ctx, span := c.start(ctx, "Update", obj, obj.GetNamespace(), obj.GetName())
+	return end(span, c.Client.Update(ctx, obj, opts...))
+}
+
+func (c *tracingClient) Patch(
+	ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption,
+) error {
+	ctx, span := c.start(ctx, "Patch", obj, obj.GetNamespace(), obj.GetName())
+	return end(span, c.Client.Patch(ctx, obj, patch, opts...))
+}
+
+func (c *tracingClient) Delete(
+	ctx context.Context, obj client.Object, opts ...client.DeleteOption,
+) error {
+	ctx, span := c.start(ctx, "Delete", obj, obj.GetNamespace(), obj.GetName())
+	return end(span, c.Client.Delete(ctx, obj, opts...))
+}
+
+func (c *tracingClient) DeleteAllOf(
+	ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption,
+) error {
+	ctx, span := c.start(ctx, "DeleteAllOf", obj, obj.GetNamespace(), "")
+	return end(span, c.Client.DeleteAllOf(ctx, obj, opts...))
+}
+
+func (c *tracingClient) Status() client.SubResourceWriter {
+	return c.SubResource("status")
+}
+
+func (c *tracingClient) SubResource(subResource string) client.SubResourceClient {
+	return &tracingSubResourceClient{
+		SubResourceClient: c.Client.SubResource(subResource),
+		client:            c,
+		subResource:       subResource,
+	}
+}
+
+//-------------------------------------------------------------------------------------------------
+// SUBRESOURCES
+//-------------------------------------------------------------------------------------------------
+
+type tracingSubResourceClient struct {
+	client.SubResourceClient
+	client      *tracingClient
+	subResource string
+}
+
+func (c *tracingSubResourceClient) Get(
+	ctx context.Context, obj, subResource client.Object, opts ...client.SubResourceGetOption,
+) error {
+	ctx, span := c.start(ctx, "Get", obj)
+	return end(span, c.SubResourceClient.Get(ctx, obj, subResource, opts...))
+}
+
+func (c *tracingSubResourceClient) Create(
+	ctx context.Context,
+	obj, subResource client.Object,
+	opts ...client.SubResourceCreateOption,
+) error {
+	ctx, span := c.start(ctx, "Create", obj)
+	return end(span, c.SubResourceClient.Create(ctx, obj, subResource, opts...))
+}
+
+func (c *tracingSubResourceClient) Update(
+	ctx context.Context, obj client.Object, opts ...client.SubResourceUpdateOption,
+) error {
+	ctx, span := c.start(ctx, "Update", obj)
+	return end(span, c.SubResourceClient.Update(ctx, obj, opts...))
+}
+
+func (c *tracingSubResourceClient) Patch(
+	ctx context.Context,
+	obj client.Object,
+	patch client.Patch,
+	opts ...client.SubResourcePatchOption,
+) error {
+	ctx, span := c.start(ctx, "Patch", obj)
+	return end(span, c.SubResourceClient.Patch(ctx, obj, patch, opts...))
+}
+
+func (c *tracingSubResourceClient) start(
+	ctx context.Context, operation string, obj client.Object,
+) (context.Context, trace.Span) {
+	ctx, span := c.client.start(ctx, operation, obj, obj.GetNamespace(), obj.GetName())
+	span.SetAttributes(attribute.String("k8s.subresource", c.subResource))
+	return ctx, span
+}
+
+//-------------------------------------------------------------------------------------------------
+// UTILS
+//-------------------------------------------------------------------------------------------------
+
+func (c *tracingClient) start(
+	ctx context.Context, operation string, obj runtime.Object, namespace, name string,
+) (context.Context, trace.Span) {
+	kind := ""
+	if gvk, err := apiutil.GVKForObject(obj, c.Scheme()); err == nil {
+		kind = gvk.Kind
+	}
+	attributes := []attribute.KeyValue{attribute.String("k8s.kind", kind)}
+	if namespace != "" {
+		attributes = append(attributes, attribute.String("k8s.namespace", namespace))
+	}
+	if name != "" {
+		attributes = append(attributes, attribute.String("k8s.name", name))
+	}
+	return c.tracer.Start(ctx, operation+" "+kind,
+		trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attributes...),
+	)
+}
+
+// end records the given error (if any) on the span, ends the span and returns the error.
+func end(span trace.Span, err error) error {
+	RecordError(span, err)
+	span.End()
+	return err
+}
+
+// RecordError marks the span as failed if the given error is not nil.
+func RecordError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
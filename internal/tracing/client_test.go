@@ -0,0 +1,47 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.Nil(t, clientgoscheme.AddToScheme(scheme))
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	c := NewClient(fake.NewClientBuilder().WithScheme(scheme).Build(), provider)
+
+	// Successful calls should be traced
+	configMap := &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "my-config", Namespace: "ns"}}
+	require.Nil(t, c.Create(ctx, configMap))
+	require.Nil(t, c.Get(ctx, client.ObjectKeyFromObject(configMap), configMap))
+
+	// Failed calls should be traced as errors
+	missing := &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "missing", Namespace: "ns"}}
+	require.NotNil(t, c.Delete(ctx, missing))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	assert.Equal(t, "Create ConfigMap", spans[0].Name())
+	assert.Contains(t, spans[0].Attributes(), attribute.String("k8s.name", "my-config"))
+	assert.Contains(t, spans[0].Attributes(), attribute.String("k8s.namespace", "ns"))
+	assert.Equal(t, "Get ConfigMap", spans[1].Name())
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+	assert.Equal(t, "Delete ConfigMap", spans[2].Name())
+	assert.Equal(t, codes.Error, spans[2].Status().Code)
+}
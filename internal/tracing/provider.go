@@ -0,0 +1,86 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// ProtocolGRPC exports spans via OTLP over gRPC.
+	ProtocolGRPC = "grpc"
+	// ProtocolHTTP exports spans via OTLP over HTTP using protobuf encoding.
+	ProtocolHTTP = "http"
+
+	serviceName = "switchboard"
+	tracerName  = "github.com/borchero/switchboard"
+)
+
+// Options configure the export of spans.
+type Options struct {
+	// Endpoint is the host and port of the OTLP receiver, e.g. `otel-collector:4317`.
+	Endpoint string
+	// Protocol is the OTLP protocol, i.e. one of `grpc` (default) and `http`.
+	Protocol string
+	// Insecure disables TLS for the connection to the OTLP receiver.
+	Insecure bool
+	// SamplingRatio is the fraction of reconciliations that are traced.
+	SamplingRatio float64
+}
+
+// NewProvider initializes a new tracer provider which exports spans via OTLP. The provider must
+// be shut down to flush pending spans.
+func NewProvider(ctx context.Context, options Options) (*sdktrace.TracerProvider, error) {
+	exporter, err := newExporter(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize exporter: %w", err)
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL, semconv.ServiceName(serviceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize resource: %w", err)
+	}
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(
+			sdktrace.TraceIDRatioBased(options.SamplingRatio),
+		)),
+	), nil
+}
+
+// Tracer returns the tracer of the given provider that all spans of Switchboard are created with.
+func Tracer(provider trace.TracerProvider) trace.Tracer {
+	return provider.Tracer(tracerName)
+}
+
+//-------------------------------------------------------------------------------------------------
+// UTILS
+//-------------------------------------------------------------------------------------------------
+
+func newExporter(ctx context.Context, options Options) (*otlptrace.Exporter, error) {
+	switch options.Protocol {
+	case "", ProtocolGRPC:
+		grpcOptions := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(options.Endpoint)}
+		if options.Insecure {
+			grpcOptions = append(grpcOptions, otlptracegrpc.WithInsecure())
+		}
+		return otlptracegrpc.New(ctx, grpcOptions...)
+	case ProtocolHTTP:
+		httpOptions := []otlptracehttp.Option{otlptracehttp.WithEndpoint(options.Endpoint)}
+		if options.Insecure {
+			httpOptions = append(httpOptions, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, httpOptions...)
+	default:
+		return nil, fmt.Errorf("unknown protocol %q", options.Protocol)
+	}
+}
@@ -0,0 +1,19 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProvider(t *testing.T) {
+	_, err := NewProvider(context.Background(), Options{Endpoint: "localhost:4317", Protocol: "udp"})
+	require.NotNil(t, err)
+
+	provider, err := NewProvider(context.Background(), Options{
+		Endpoint: "localhost:4318", Protocol: ProtocolHTTP, Insecure: true,
+	})
+	require.Nil(t, err)
+	require.Nil(t, provider.Shutdown(context.Background()))
+}